@@ -0,0 +1,220 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+// References:
+//   [BIP44]: Multi-Account Hierarchy for Deterministic Wallets
+//   https://github.com/bitcoin/bips/blob/master/bip-0044.mediawiki
+//   [BIP49]: Derivation scheme for P2WPKH-nested-in-P2SH based accounts
+//   https://github.com/bitcoin/bips/blob/master/bip-0049.mediawiki
+//   [BIP84]: Derivation scheme for P2WPKH based accounts
+//   https://github.com/bitcoin/bips/blob/master/bip-0084.mediawiki
+//   [BIP86]: Key Derivation for Single Key P2TR Outputs
+//   https://github.com/bitcoin/bips/blob/master/bip-0086.mediawiki
+
+import (
+	"errors"
+	"fmt"
+)
+
+//Purpose is the purpose level of the BIP43 derivation path, which decides
+//the address type of the account.
+type Purpose uint32
+
+//Purposes.
+const (
+	//Purpose44 is for P2PKH addresses.
+	Purpose44 Purpose = 44
+	//Purpose49 is for P2SH-P2WPKH (nested segwit) addresses.
+	Purpose49 Purpose = 49
+	//Purpose84 is for P2WPKH (native segwit) addresses.
+	Purpose84 Purpose = 84
+	//Purpose86 is for P2TR (taproot) addresses.
+	Purpose86 Purpose = 86
+)
+
+//Chain indexes of an account.
+const (
+	//ExternalChain is the chain of receiving addresses.
+	ExternalChain uint32 = 0
+	//InternalChain is the chain of change addresses.
+	InternalChain uint32 = 1
+)
+
+//ErrUnknownPurpose is returned when the purpose is not one of Purpose44,
+//Purpose49, Purpose84 and Purpose86.
+var ErrUnknownPurpose = errors.New("unknown purpose")
+
+//AddressType returns the address type used by accounts with the purpose.
+func (p Purpose) AddressType() (AddressType, error) {
+	switch p {
+	case Purpose44:
+		return P2PKH, nil
+	case Purpose49:
+		return P2SH, nil
+	case Purpose84:
+		return P2WPKH, nil
+	case Purpose86:
+		return P2TR, nil
+	}
+	return 0, ErrUnknownPurpose
+}
+
+//Account is an account of m/purpose'/coin_type'/account'.
+type Account struct {
+	key     *ExtendedKey
+	purpose Purpose
+	path    DerivationPath
+}
+
+//NewAccount derives the account m/purpose'/coinType'/account' from the master
+//key. Use CoinType of Params for coinType.
+//ErrInvalidPath is returned if coinType or account is not less than
+//HardenedKeyStart.
+func NewAccount(master *ExtendedKey, purpose Purpose, coinType, account uint32) (*Account, error) {
+	if _, err := purpose.AddressType(); err != nil {
+		return nil, err
+	}
+	if coinType >= HardenedKeyStart {
+		return nil, fmt.Errorf("%w: coin type %d", ErrInvalidPath, coinType)
+	}
+	if account >= HardenedKeyStart {
+		return nil, fmt.Errorf("%w: account %d", ErrInvalidPath, account)
+	}
+	path := DerivationPath{
+		HardenedKeyStart + uint32(purpose),
+		HardenedKeyStart + coinType,
+		HardenedKeyStart + account,
+	}
+	key, err := master.DerivePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		key:     key,
+		purpose: purpose,
+		path:    path,
+	}, nil
+}
+
+//Key returns the extended key of the account.
+func (a *Account) Key() *ExtendedKey {
+	return a.key
+}
+
+//Purpose returns the purpose of the account.
+func (a *Account) Purpose() Purpose {
+	return a.purpose
+}
+
+//Path returns the derivation path of the account from the master key.
+func (a *Account) Path() DerivationPath {
+	return append(DerivationPath{}, a.path...)
+}
+
+//Neuter returns the watch-only account which has the extended public key.
+func (a *Account) Neuter() (*Account, error) {
+	key, err := a.key.Neuter()
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		key:     key,
+		purpose: a.purpose,
+		path:    a.path,
+	}, nil
+}
+
+//Chain returns the chain of the account, ExternalChain or InternalChain.
+func (a *Account) Chain(change uint32) (*Chain, error) {
+	key, err := a.key.Child(change)
+	if err != nil {
+		return nil, err
+	}
+	return &Chain{
+		key:     key,
+		purpose: a.purpose,
+		path:    append(a.Path(), change),
+	}, nil
+}
+
+//ReceiveChain returns the external chain of the account.
+func (a *Account) ReceiveChain() (*Chain, error) {
+	return a.Chain(ExternalChain)
+}
+
+//ChangeChain returns the internal chain of the account.
+func (a *Account) ChangeChain() (*Chain, error) {
+	return a.Chain(InternalChain)
+}
+
+//Chain is the receiving or change chain of an account.
+type Chain struct {
+	key     *ExtendedKey
+	purpose Purpose
+	path    DerivationPath
+}
+
+//Path returns the derivation path of the chain from the master key.
+func (c *Chain) Path() DerivationPath {
+	return append(DerivationPath{}, c.path...)
+}
+
+//Key returns the extended key at index of the chain.
+func (c *Chain) Key(index uint32) (*ExtendedKey, error) {
+	return c.key.Child(index)
+}
+
+//Address returns the address at index of the chain, whose type depends on
+//the purpose of the account.
+func (c *Chain) Address(index uint32) (Address, error) {
+	key, err := c.Key(index)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := key.PubKey()
+	if err != nil {
+		return nil, err
+	}
+	switch c.purpose {
+	case Purpose44:
+		return NewP2PKHAddress(pub.AddressBytes(), pub.param)
+	case Purpose49:
+		return NewP2SHAddress(AddressBytes(pub.NestedWitnessRedeemScript()), pub.param)
+	case Purpose84:
+		return NewP2WPKHAddress(pub.WitnessProgram(), pub.param)
+	case Purpose86:
+		q, err := pub.TaprootOutputKey(nil)
+		if err != nil {
+			return nil, err
+		}
+		return NewP2TRAddress(q, pub.param)
+	}
+	return nil, ErrUnknownPurpose
+}
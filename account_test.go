@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"errors"
+	"testing"
+)
+
+//test vectors from BIP44, BIP49, BIP84 and BIP86.
+func TestAccount(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	tests := []struct {
+		param   *Params
+		purpose Purpose
+		change  uint32
+		index   uint32
+		path    string
+		address string
+	}{
+		{BitcoinMain, Purpose44, 0, 0, "m/44'/0'/0'/0/0", "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},
+		{BitcoinTest, Purpose49, 0, 0, "m/49'/1'/0'/0/0", "2Mww8dCYPUpKHofjgcXcBCEGmniw9CoaiD2"},
+		{BitcoinMain, Purpose84, 0, 0, "m/84'/0'/0'/0/0", "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+		{BitcoinMain, Purpose84, 0, 1, "m/84'/0'/0'/0/1", "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g"},
+		{BitcoinMain, Purpose84, 1, 0, "m/84'/0'/0'/1/0", "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el"},
+		{BitcoinMain, Purpose86, 0, 0, "m/86'/0'/0'/0/0", "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"},
+	}
+	for _, test := range tests {
+		master, err := NewMaster(NewSeed(mnemonic, ""), test.param)
+		if err != nil {
+			t.Fatal(err)
+		}
+		acc, err := NewAccount(master, test.purpose, test.param.CoinType, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain, err := acc.Chain(test.change)
+		if err != nil {
+			t.Fatal(err)
+		}
+		adr, err := chain.Address(test.index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if adr.String() != test.address {
+			t.Error(test.path, "invalid address", adr)
+		}
+		if p := append(chain.Path(), test.index).String(); p != test.path {
+			t.Error("invalid path", p)
+		}
+		typ, err := test.purpose.AddressType()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if adr.Type() != typ {
+			t.Error(test.path, "invalid address type", adr.Type())
+		}
+
+		//watch-only account must give the same address.
+		wacc, err := acc.Neuter()
+		if err != nil {
+			t.Fatal(err)
+		}
+		wchain, err := wacc.Chain(test.change)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wadr, err := wchain.Address(test.index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wadr.String() != test.address {
+			t.Error(test.path, "invalid watch-only address", wadr)
+		}
+	}
+
+	master, err := NewMaster(NewSeed(mnemonic, ""), BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewAccount(master, Purpose(45), 0, 0); err != ErrUnknownPurpose {
+		t.Error("purpose 45 should be unknown")
+	}
+	for _, v := range [][2]uint32{{HardenedKeyStart, 0}, {0, HardenedKeyStart}, {0, 0xffffffff}} {
+		if _, err := NewAccount(master, Purpose84, v[0], v[1]); !errors.Is(err, ErrInvalidPath) {
+			t.Error("coin type or account out of range should be rejected", v, err)
+		}
+	}
+}
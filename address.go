@@ -0,0 +1,434 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/bitgoin/address/base58"
+	"github.com/bitgoin/address/bech32"
+	"github.com/bitgoin/address/cashaddr"
+)
+
+//AddressType is the type of output script an address represents.
+type AddressType int
+
+//Address types.
+const (
+	P2PKH AddressType = iota
+	P2SH
+	P2WPKH
+	P2WSH
+	P2TR
+)
+
+var addressTypeNames = map[AddressType]string{
+	P2PKH:  "p2pkh",
+	P2SH:   "p2sh",
+	P2WPKH: "p2wpkh",
+	P2WSH:  "p2wsh",
+	P2TR:   "p2tr",
+}
+
+//String returns the name of the address type.
+func (t AddressType) String() string {
+	if n, ok := addressTypeNames[t]; ok {
+		return n
+	}
+	return "unknown"
+}
+
+var (
+	//ErrUnknownAddressFormat is returned when the address is neither base58
+	//nor bech32.
+	ErrUnknownAddressFormat = errors.New("unknown address format")
+
+	//ErrUnknownNetwork is returned when the prefix of the address doesn't
+	//match any of the given Params.
+	ErrUnknownNetwork = errors.New("address does not belong to the networks")
+
+	//ErrInvalidAddressLength is returned when the hash or witness program in
+	//the address has the wrong length.
+	ErrInvalidAddressLength = errors.New("invalid address length")
+
+	//ErrUnsupportedWitnessVersion is returned when the witness version of the
+	//address is neither 0 nor 1.
+	ErrUnsupportedWitnessVersion = errors.New("unsupported witness version")
+
+	//ErrNoCashAddr is returned when the network of the address doesn't use
+	//CashAddr.
+	ErrNoCashAddr = errors.New("network has no CashAddr prefix")
+)
+
+//Address is a bitcoin address of any type.
+type Address interface {
+	//String returns the encoded address.
+	String() string
+	//ScriptPubKey returns the output script paying to the address.
+	ScriptPubKey() []byte
+	//Params returns the network of the address.
+	Params() *Params
+	//Type returns the type of the address.
+	Type() AddressType
+}
+
+//P2PKHAddress is a pay-to-pubkey-hash address.
+type P2PKHAddress struct {
+	hash  []byte
+	param *Params
+	addr  string
+}
+
+//NewP2PKHAddress returns P2PKHAddress from 20 bytes hash of a public key.
+func NewP2PKHAddress(hash []byte, param *Params) (*P2PKHAddress, error) {
+	if len(hash) != 20 {
+		return nil, ErrInvalidAddressLength
+	}
+	h := append([]byte{}, hash...)
+	return &P2PKHAddress{
+		hash:  h,
+		param: param,
+		addr:  base58.Encode(append(append([]byte{}, param.AddressHeader...), h...)),
+	}, nil
+}
+
+//Hash returns the public key hash.
+func (a *P2PKHAddress) Hash() []byte { return a.hash }
+
+//String returns the encoded address.
+func (a *P2PKHAddress) String() string { return a.addr }
+
+//Params returns the network of the address.
+func (a *P2PKHAddress) Params() *Params { return a.param }
+
+//Type returns P2PKH.
+func (a *P2PKHAddress) Type() AddressType { return P2PKH }
+
+//ScriptPubKey returns OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG.
+func (a *P2PKHAddress) ScriptPubKey() []byte {
+	s := append([]byte{0x76, 0xa9, 0x14}, a.hash...)
+	return append(s, 0x88, 0xac)
+}
+
+//CashAddr returns the address in CashAddr format, such as
+//"bitcoincash:q...".
+func (a *P2PKHAddress) CashAddr() (string, error) {
+	return encodeCashAddr(a.param, cashaddr.P2KH, a.hash)
+}
+
+//P2SHAddress is a pay-to-script-hash address.
+type P2SHAddress struct {
+	hash  []byte
+	param *Params
+	addr  string
+}
+
+//NewP2SHAddress returns P2SHAddress from 20 bytes hash of a redeem script.
+func NewP2SHAddress(hash []byte, param *Params) (*P2SHAddress, error) {
+	if len(hash) != 20 {
+		return nil, ErrInvalidAddressLength
+	}
+	h := append([]byte{}, hash...)
+	return &P2SHAddress{
+		hash:  h,
+		param: param,
+		addr:  base58.Encode(append(append([]byte{}, param.P2SHHeader...), h...)),
+	}, nil
+}
+
+//Hash returns the script hash.
+func (a *P2SHAddress) Hash() []byte { return a.hash }
+
+//String returns the encoded address.
+func (a *P2SHAddress) String() string { return a.addr }
+
+//Params returns the network of the address.
+func (a *P2SHAddress) Params() *Params { return a.param }
+
+//Type returns P2SH.
+func (a *P2SHAddress) Type() AddressType { return P2SH }
+
+//ScriptPubKey returns OP_HASH160 <hash> OP_EQUAL.
+func (a *P2SHAddress) ScriptPubKey() []byte {
+	s := append([]byte{0xa9, 0x14}, a.hash...)
+	return append(s, 0x87)
+}
+
+//CashAddr returns the address in CashAddr format, such as
+//"bitcoincash:p...".
+func (a *P2SHAddress) CashAddr() (string, error) {
+	return encodeCashAddr(a.param, cashaddr.P2SH, a.hash)
+}
+
+//encodeCashAddr encodes hash with the CashAddr prefix of param.
+func encodeCashAddr(param *Params, typ byte, hash []byte) (string, error) {
+	if param.CashAddrPrefix == "" {
+		return "", ErrNoCashAddr
+	}
+	return cashaddr.Encode(param.CashAddrPrefix, typ, hash)
+}
+
+//witnessAddress is the common part of segwit addresses.
+type witnessAddress struct {
+	version byte
+	program []byte
+	param   *Params
+	addr    string
+}
+
+func newWitnessAddress(version byte, program []byte, param *Params) (witnessAddress, error) {
+	p := append([]byte{}, program...)
+	adr, err := bech32.EncodeSegwit(param.Bech32HRP, version, p)
+	if err != nil {
+		return witnessAddress{}, err
+	}
+	return witnessAddress{
+		version: version,
+		program: p,
+		param:   param,
+		addr:    adr,
+	}, nil
+}
+
+//Program returns the witness program.
+func (a *witnessAddress) Program() []byte { return a.program }
+
+//String returns the encoded address.
+func (a *witnessAddress) String() string { return a.addr }
+
+//Params returns the network of the address.
+func (a *witnessAddress) Params() *Params { return a.param }
+
+//ScriptPubKey returns OP_n <program>.
+func (a *witnessAddress) ScriptPubKey() []byte {
+	op := a.version
+	if op != 0 {
+		op += 0x50
+	}
+	return append([]byte{op, byte(len(a.program))}, a.program...)
+}
+
+//P2WPKHAddress is a native segwit pay-to-witness-pubkey-hash address.
+type P2WPKHAddress struct {
+	witnessAddress
+}
+
+//NewP2WPKHAddress returns P2WPKHAddress from 20 bytes hash of a compressed
+//public key.
+func NewP2WPKHAddress(hash []byte, param *Params) (*P2WPKHAddress, error) {
+	if len(hash) != 20 {
+		return nil, ErrInvalidAddressLength
+	}
+	w, err := newWitnessAddress(0, hash, param)
+	if err != nil {
+		return nil, err
+	}
+	return &P2WPKHAddress{w}, nil
+}
+
+//Type returns P2WPKH.
+func (a *P2WPKHAddress) Type() AddressType { return P2WPKH }
+
+//P2WSHAddress is a native segwit pay-to-witness-script-hash address.
+type P2WSHAddress struct {
+	witnessAddress
+}
+
+//NewP2WSHAddress returns P2WSHAddress from 32 bytes sha256 of a witness
+//script.
+func NewP2WSHAddress(hash []byte, param *Params) (*P2WSHAddress, error) {
+	if len(hash) != 32 {
+		return nil, ErrInvalidAddressLength
+	}
+	w, err := newWitnessAddress(0, hash, param)
+	if err != nil {
+		return nil, err
+	}
+	return &P2WSHAddress{w}, nil
+}
+
+//Type returns P2WSH.
+func (a *P2WSHAddress) Type() AddressType { return P2WSH }
+
+//P2TRAddress is a segwit v1 pay-to-taproot address.
+type P2TRAddress struct {
+	witnessAddress
+}
+
+//NewP2TRAddress returns P2TRAddress from 32 bytes x-only output key.
+func NewP2TRAddress(key []byte, param *Params) (*P2TRAddress, error) {
+	if len(key) != 32 {
+		return nil, ErrInvalidAddressLength
+	}
+	w, err := newWitnessAddress(1, key, param)
+	if err != nil {
+		return nil, err
+	}
+	return &P2TRAddress{w}, nil
+}
+
+//Type returns P2TR.
+func (a *P2TRAddress) Type() AddressType { return P2TR }
+
+//ParseAddress decodes the address s and returns it as P2PKHAddress,
+//P2SHAddress, P2WPKHAddress, P2WSHAddress or P2TRAddress.
+//The prefix of the address must match one of params, or one of the
+//registered networks if params is empty. If several networks share the prefix,
+//the first one in params wins.
+//CashAddr addresses, with or without the prefix, are parsed as P2PKHAddress
+//or P2SHAddress whose String returns the legacy base58 form.
+func ParseAddress(s string, params ...*Params) (Address, error) {
+	if len(params) == 0 {
+		params = RegisteredParams()
+	}
+	if p := findCashAddrParams(s, params); p != nil {
+		return parseCashAddr(s, p)
+	}
+	if isBech32Address(s, params) {
+		return parseWitnessAddress(s, params)
+	}
+	return parseBase58Address(s, params)
+}
+
+//findCashAddrParams returns the network of params whose CashAddr prefix is
+//the prefix of s, or whose prefix makes the checksum of s valid if s has no
+//prefix. It returns nil if s is not a CashAddr address of params.
+func findCashAddrParams(s string, params []*Params) *Params {
+	ls := strings.ToLower(s)
+	prefixed := strings.Contains(ls, ":")
+	for _, p := range params {
+		if p.CashAddrPrefix == "" {
+			continue
+		}
+		if prefixed && strings.HasPrefix(ls, p.CashAddrPrefix+":") {
+			return p
+		}
+		if !prefixed {
+			if _, _, _, err := cashaddr.Decode(s, p.CashAddrPrefix); err == nil {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+func parseCashAddr(s string, param *Params) (Address, error) {
+	_, typ, hash, err := cashaddr.Decode(s, param.CashAddrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	switch typ {
+	case cashaddr.P2KH:
+		return NewP2PKHAddress(hash, param)
+	case cashaddr.P2SH:
+		return NewP2SHAddress(hash, param)
+	}
+	return nil, ErrUnknownAddressFormat
+}
+
+//isBech32Address returns true if s starts with the bech32 hrp of params.
+func isBech32Address(s string, params []*Params) bool {
+	ls := strings.ToLower(s)
+	for _, p := range params {
+		if p.Bech32HRP != "" && strings.HasPrefix(ls, p.Bech32HRP+"1") {
+			return true
+		}
+	}
+	return false
+}
+
+func parseWitnessAddress(s string, params []*Params) (Address, error) {
+	hrp, _, _, err := bech32.DecodeVariant(s)
+	if err != nil {
+		return nil, err
+	}
+	var param *Params
+	for _, p := range params {
+		if p.Bech32HRP == hrp {
+			param = p
+			break
+		}
+	}
+	if param == nil {
+		return nil, ErrUnknownNetwork
+	}
+	version, program, err := bech32.DecodeSegwit(hrp, s)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case version == 0 && len(program) == 20:
+		return NewP2WPKHAddress(program, param)
+	case version == 0 && len(program) == 32:
+		return NewP2WSHAddress(program, param)
+	case version == 1 && len(program) == 32:
+		return NewP2TRAddress(program, param)
+	case version == 1:
+		return nil, ErrInvalidAddressLength
+	}
+	return nil, ErrUnsupportedWitnessVersion
+}
+
+func parseBase58Address(s string, params []*Params) (Address, error) {
+	pb, err := base58.Decode(s)
+	if err != nil {
+		if _, ok := err.(base58.CorruptInputError); !ok {
+			return nil, err
+		}
+		//valid bech32 string with an unknown hrp.
+		if _, _, _, err := bech32.DecodeVariant(s); err == nil {
+			return nil, ErrUnknownNetwork
+		}
+		return nil, ErrUnknownAddressFormat
+	}
+	for _, p := range params {
+		if hash, ok := trimHeader(pb, p.AddressHeader); ok {
+			return NewP2PKHAddress(hash, p)
+		}
+		if hash, ok := trimHeader(pb, p.P2SHHeader); ok {
+			return NewP2SHAddress(hash, p)
+		}
+	}
+	for _, p := range params {
+		if bytes.HasPrefix(pb, p.AddressHeader) || bytes.HasPrefix(pb, p.P2SHHeader) {
+			return nil, ErrInvalidAddressLength
+		}
+	}
+	return nil, ErrUnknownNetwork
+}
+
+//trimHeader returns the 20 bytes hash after header if pb is header||hash.
+func trimHeader(pb, header []byte) ([]byte, bool) {
+	if len(header) == 0 || len(pb) != len(header)+20 || !bytes.HasPrefix(pb, header) {
+		return nil, false
+	}
+	return pb[len(header):], true
+}
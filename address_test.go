@@ -0,0 +1,225 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/bitgoin/address/base58"
+	"github.com/bitgoin/address/bech32"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		addr         string
+		typ          AddressType
+		param        *Params
+		scriptPubKey string
+	}{
+		{
+			addr:         "1MirQ9bwyQcGVJPwKUgapu5ouK2E2Ey4gX",
+			typ:          P2PKH,
+			param:        BitcoinMain,
+			scriptPubKey: "76a914e34cce70c86373273efcc54ce7d2a491bb4a0e8488ac",
+		},
+		{
+			addr:         "mrX9vMRYLfVy1BnZbc5gZjuyaqH3ZW2ZHz",
+			typ:          P2PKH,
+			param:        BitcoinTest,
+			scriptPubKey: "76a91478b316a08647d5b77283e512d3603f1f1c8de68f88ac",
+		},
+		{
+			addr:         "3NukJ6fYZJ5Kk8bPjycAnruZkE5Q7UW7i8",
+			typ:          P2SH,
+			param:        BitcoinMain,
+			scriptPubKey: "a914e8c300c87986efa84c37c0519929019ef86eb5b487",
+		},
+		{
+			addr:         "2NBFNJTktNa7GZusGbDbGKRZTxdK9VVez3n",
+			typ:          P2SH,
+			param:        BitcoinTest,
+			scriptPubKey: "a914c579342c2c4c9220205e2cdc285617040c924a0a87",
+		},
+		{
+			addr:         "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			typ:          P2WPKH,
+			param:        BitcoinMain,
+			scriptPubKey: "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+		},
+		{
+			addr:         "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7",
+			typ:          P2WSH,
+			param:        BitcoinTest,
+			scriptPubKey: "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262",
+		},
+		{
+			addr:         "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0",
+			typ:          P2TR,
+			param:        BitcoinMain,
+			scriptPubKey: "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+		},
+	}
+	for _, test := range tests {
+		adr, err := ParseAddress(test.addr)
+		if err != nil {
+			t.Error(test.addr, err)
+			continue
+		}
+		if adr.Type() != test.typ {
+			t.Error(test.addr, "invalid type", adr.Type())
+		}
+		if adr.Params() != test.param {
+			t.Error(test.addr, "invalid params")
+		}
+		if hex.EncodeToString(adr.ScriptPubKey()) != test.scriptPubKey {
+			t.Error(test.addr, "invalid scriptPubKey", hex.EncodeToString(adr.ScriptPubKey()))
+		}
+		if adr.String() != test.addr {
+			t.Error(test.addr, "invalid string", adr.String())
+		}
+	}
+
+	//Monacoin shares P2SH header with bitcoin mainnet, so params decides.
+	adr, err := ParseAddress("3NukJ6fYZJ5Kk8bPjycAnruZkE5Q7UW7i8", MonacoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adr.Params() != MonacoinMain {
+		t.Error("invalid params")
+	}
+
+	short := base58.Encode(append([]byte{0}, make([]byte, 19)...))
+	invalid := []struct {
+		addr   string
+		params []*Params
+		err    error
+	}{
+		{"1MirQ9bwyQcGVJPwKUgapu5ouK2E2Ey4gX", []*Params{BitcoinTest}, ErrUnknownNetwork},
+		{"1MirQ9bwyQcGVJPwKUgapu5ouK2E2Ey4gY", nil, base58.ErrChecksum},
+		{short, nil, ErrInvalidAddressLength},
+		{"0OIl0OIl0OIl", nil, ErrUnknownAddressFormat},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", []*Params{BitcoinTest}, ErrUnknownNetwork},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", nil, bech32.ErrInvalidChecksum},
+		{"bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs", nil, ErrUnsupportedWitnessVersion},
+	}
+	for _, test := range invalid {
+		if _, err := ParseAddress(test.addr, test.params...); err != test.err {
+			t.Error(test.addr, "expected", test.err, "but got", err)
+		}
+	}
+}
+
+func TestAddressConstructors(t *testing.T) {
+	pb, err := hex.DecodeString("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := NewPublicKey(pb, BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2pkh, err := NewP2PKHAddress(pub.AddressBytes(), BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p2pkh.String() != pub.Address() {
+		t.Error("invalid p2pkh address", p2pkh)
+	}
+	p2wpkh, err := NewP2WPKHAddress(pub.AddressBytes(), BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adr, _ := pub.WitnessAddress(); p2wpkh.String() != adr {
+		t.Error("invalid p2wpkh address", p2wpkh)
+	}
+	if _, err := NewP2WSHAddress(pub.AddressBytes(), BitcoinMain); err != ErrInvalidAddressLength {
+		t.Error("p2wsh must be 32 bytes")
+	}
+}
+
+func TestCashAddr(t *testing.T) {
+	for _, test := range []struct {
+		cash   string
+		legacy string
+		typ    AddressType
+		param  *Params
+	}{
+		{"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", P2PKH, BitcoinCashMain},
+		{"bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq", "3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC", P2SH, BitcoinCashMain},
+		{"bchtest:qp63uahgrxged4z5jswyt5dn5v3lzsem6cq85x00dt", "mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r", P2PKH, BitcoinCashTest},
+	} {
+		for _, s := range []string{test.cash, strings.ToUpper(test.cash), test.cash[strings.IndexByte(test.cash, ':')+1:]} {
+			adr, err := ParseAddress(s)
+			if err != nil {
+				t.Fatal(s, err)
+			}
+			if adr.Type() != test.typ || adr.Params() != test.param || adr.String() != test.legacy {
+				t.Error("invalid address", s, adr.Type(), adr.Params().Name, adr)
+			}
+			var cash string
+			switch a := adr.(type) {
+			case *P2PKHAddress:
+				cash, err = a.CashAddr()
+			case *P2SHAddress:
+				cash, err = a.CashAddr()
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cash != test.cash {
+				t.Error("invalid cashaddr", cash)
+			}
+		}
+		adr, err := ParseAddress(test.legacy, test.param)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if adr.Params() != test.param {
+			t.Error("invalid network of legacy address", adr)
+		}
+	}
+
+	pb := make([]byte, 32)
+	pb[31] = 1
+	cash, err := NewPrivateKey(pb, BitcoinCashTest).PublicKey.CashAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cash != "bchtest:qp63uahgrxged4z5jswyt5dn5v3lzsem6cq85x00dt" {
+		t.Error("invalid cashaddr", cash)
+	}
+	if _, err := NewPrivateKey(pb, BitcoinMain).PublicKey.CashAddress(); err != ErrNoCashAddr {
+		t.Error("bitcoin must not have cashaddr", err)
+	}
+	if _, err := ParseAddress("bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", BitcoinMain); err == nil {
+		t.Error("cashaddr must not be parsed for bitcoin")
+	}
+}
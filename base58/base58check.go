@@ -23,12 +23,18 @@ package base58
 import (
 	"bytes"
 	"crypto/sha256"
-	"encoding/hex"
 	"errors"
-	"fmt"
 	"math/big"
 )
 
+var (
+	//ErrChecksum is returned when the checksum doesn't match.
+	ErrChecksum = errors.New("base58 checksum error")
+	//ErrInvalidFormat is returned when the version byte and/or the
+	//checksum bytes are missing.
+	ErrInvalidFormat = errors.New("invalid base58check format")
+)
+
 //Encode encodes byteData to base58.
 func Encode(encoded []byte) string {
 	//Perform SHA-256 twice
@@ -66,36 +72,34 @@ func Encode(encoded []byte) string {
 //Decode decodes base58 value to bytes.
 func Decode(value string) ([]byte, error) {
 	if len(value) < 5 {
-		return nil, errors.New("invalid input")
+		return nil, ErrInvalidFormat
 	}
 	publicKeyInt, err := DecodeToBig([]byte(value))
 	if err != nil {
 		return nil, err
 	}
 
-	encodedChecksum := publicKeyInt.Bytes()
-	encoded := encodedChecksum[:len(encodedChecksum)-4]
-	cksum := encodedChecksum[len(encodedChecksum)-4:]
-
-	buffer := make([]byte, 0, len(encoded))
+	buffer := make([]byte, 0, len(value))
 	for _, v := range value {
 		if v != '1' {
 			break
 		}
 		buffer = append(buffer, 0)
 	}
-
-	buffer = append(buffer, encoded...)
+	buffer = append(buffer, publicKeyInt.Bytes()...)
+	if len(buffer) < 5 {
+		return nil, ErrInvalidFormat
+	}
+	cksum := buffer[len(buffer)-4:]
+	buffer = buffer[:len(buffer)-4]
 
 	//Perform SHA-256 twice
 	hash := sha256.Sum256(buffer)
 	hash = sha256.Sum256(hash[:])
 
 	if !bytes.Equal(hash[:4], cksum) {
-		return nil,
-			fmt.Errorf("%s checksum did not match to the embeded one:%s, should be :%s",
-				value, hex.EncodeToString(hash[:4]), hex.EncodeToString(cksum))
+		return nil, ErrChecksum
 	}
 
-	return buffer, err
+	return buffer, nil
 }
@@ -42,7 +42,7 @@ func TestBase58Check(t *testing.T) {
 	// test the two decoding failure cases
 	// case 1: checksum error
 	_, err := Decode("3MNQE1Y")
-	if err == nil {
+	if err != ErrChecksum {
 		t.Error("Checkdecode test failed, expected ErrChecksum")
 	}
 	// case 2: invalid formats (string lengths below 5 mean the version byte and/or the checksum
@@ -55,5 +55,12 @@ func TestBase58Check(t *testing.T) {
 			t.Error("Checkdecode test failed, expected ErrInvalidFormat")
 		}
 	}
+	// case 3: the decoded bytes are short even though the string is long
+	// enough.
+	for _, s := range []string{"11111", "1111z", "z1111"} {
+		if _, err = Decode(s); err == nil {
+			t.Error("Checkdecode test failed, expected error", s)
+		}
+	}
 
 }
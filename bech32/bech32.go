@@ -0,0 +1,324 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+// Package bech32 implements the bech32 and bech32m encodings defined in
+// BIP173 and BIP350 and the segwit address format built on top of them.
+package bech32
+
+// References:
+//   [BIP173]: Base32 address format for native v0-16 witness outputs
+//   https://github.com/bitcoin/bips/blob/master/bip-0173.mediawiki
+//   [BIP350]: Bech32m format for v1+ witness addresses
+//   https://github.com/bitcoin/bips/blob/master/bip-0350.mediawiki
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	//maxLength is the maximum length of a bech32 string.
+	maxLength = 90
+
+	//checksumLength is the number of 5 bit groups in the checksum.
+	checksumLength = 6
+)
+
+//Variant is the checksum variant of a bech32 string.
+type Variant uint32
+
+//Checksum variants.
+const (
+	//Bech32 is the original checksum of BIP173, used by witness version 0.
+	Bech32 Variant = 1
+	//Bech32m is the checksum of BIP350, used by witness version 1 and later.
+	Bech32m Variant = 0x2bc830a3
+)
+
+var (
+	//ErrInvalidChecksum is returned when the checksum of the string is wrong.
+	ErrInvalidChecksum = errors.New("invalid bech32 checksum")
+
+	//ErrMixedCase is returned when the string contains both upper and lower
+	//case characters.
+	ErrMixedCase = errors.New("bech32 string has mixed case")
+
+	//ErrInvalidLength is returned when the string is too long or too short.
+	ErrInvalidLength = errors.New("invalid bech32 string length")
+
+	//ErrInvalidSeparator is returned when the separator '1' is missing or
+	//leaves an empty human-readable part or a too short data part.
+	ErrInvalidSeparator = errors.New("invalid bech32 separator position")
+
+	//ErrInvalidProgram is returned when the witness program doesn't follow
+	//the rules of BIP173.
+	ErrInvalidProgram = errors.New("invalid witness program")
+
+	//ErrInvalidPadding is returned when the bit conversion leaves non-zero
+	//or too many padding bits.
+	ErrInvalidPadding = errors.New("invalid padding in bech32 data")
+)
+
+var generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := uint(0); i < 5; i++ {
+			if (b>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func hrpExpand(hrp string) []byte {
+	r := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		r = append(r, hrp[i]>>5)
+	}
+	r = append(r, 0)
+	for i := 0; i < len(hrp); i++ {
+		r = append(r, hrp[i]&31)
+	}
+	return r
+}
+
+func verifyChecksum(hrp string, data []byte) (Variant, bool) {
+	switch v := Variant(polymod(append(hrpExpand(hrp), data...))); v {
+	case Bech32, Bech32m:
+		return v, true
+	}
+	return 0, false
+}
+
+func createChecksum(hrp string, data []byte, v Variant) []byte {
+	values := append(hrpExpand(hrp), data...)
+	values = append(values, make([]byte, checksumLength)...)
+	mod := polymod(values) ^ uint32(v)
+	r := make([]byte, checksumLength)
+	for i := range r {
+		r[i] = byte(mod>>uint(5*(5-i))) & 31
+	}
+	return r
+}
+
+//Encode encodes hrp and data, which is a slice of 5 bit values, to a bech32
+//string.
+func Encode(hrp string, data []byte) (string, error) {
+	return EncodeVariant(hrp, data, Bech32)
+}
+
+//EncodeM encodes hrp and data, which is a slice of 5 bit values, to a bech32m
+//string.
+func EncodeM(hrp string, data []byte) (string, error) {
+	return EncodeVariant(hrp, data, Bech32m)
+}
+
+//EncodeVariant encodes hrp and data with the checksum variant v.
+func EncodeVariant(hrp string, data []byte, v Variant) (string, error) {
+	if len(hrp)+len(data)+1+checksumLength > maxLength {
+		return "", ErrInvalidLength
+	}
+	if len(hrp) == 0 {
+		return "", ErrInvalidSeparator
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", fmt.Errorf("invalid character %q in hrp", hrp[i])
+		}
+	}
+	if strings.ToLower(hrp) != hrp && strings.ToUpper(hrp) != hrp {
+		return "", ErrMixedCase
+	}
+	hrp = strings.ToLower(hrp)
+	combined := append(append([]byte{}, data...), createChecksum(hrp, data, v)...)
+	var b strings.Builder
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, v := range combined {
+		if int(v) >= len(charset) {
+			return "", fmt.Errorf("invalid data value %d", v)
+		}
+		b.WriteByte(charset[v])
+	}
+	return b.String(), nil
+}
+
+//Decode decodes a bech32 string and returns the human-readable part and the
+//data part as 5 bit values without the checksum.
+func Decode(s string) (string, []byte, error) {
+	return decodeVariant(s, Bech32)
+}
+
+//DecodeM decodes a bech32m string and returns the human-readable part and the
+//data part as 5 bit values without the checksum.
+func DecodeM(s string) (string, []byte, error) {
+	return decodeVariant(s, Bech32m)
+}
+
+func decodeVariant(s string, v Variant) (string, []byte, error) {
+	hrp, data, variant, err := DecodeVariant(s)
+	if err != nil {
+		return "", nil, err
+	}
+	if variant != v {
+		return "", nil, ErrInvalidChecksum
+	}
+	return hrp, data, nil
+}
+
+//DecodeVariant decodes a bech32 or bech32m string and returns the
+//human-readable part, the data part as 5 bit values without the checksum and
+//the checksum variant.
+func DecodeVariant(s string) (string, []byte, Variant, error) {
+	if len(s) > maxLength || len(s) < 8 {
+		return "", nil, 0, ErrInvalidLength
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 33 || s[i] > 126 {
+			return "", nil, 0, fmt.Errorf("invalid character %q in bech32 string", s[i])
+		}
+	}
+	lower := strings.ToLower(s)
+	if lower != s && strings.ToUpper(s) != s {
+		return "", nil, 0, ErrMixedCase
+	}
+	s = lower
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+checksumLength+1 > len(s) {
+		return "", nil, 0, ErrInvalidSeparator
+	}
+	hrp := s[:pos]
+	data := make([]byte, 0, len(s)-pos-1)
+	for i := pos + 1; i < len(s); i++ {
+		d := strings.IndexByte(charset, s[i])
+		if d < 0 {
+			return "", nil, 0, fmt.Errorf("invalid character %q in bech32 data", s[i])
+		}
+		data = append(data, byte(d))
+	}
+	v, ok := verifyChecksum(hrp, data)
+	if !ok {
+		return "", nil, 0, ErrInvalidChecksum
+	}
+	return hrp, data[:len(data)-checksumLength], v, nil
+}
+
+//ConvertBits regroups data of frombits bit values to tobits bit values.
+//If pad is true, remaining bits are padded with zeros.
+func ConvertBits(data []byte, frombits, tobits uint, pad bool) ([]byte, error) {
+	acc := uint32(0)
+	bits := uint(0)
+	maxv := uint32(1)<<tobits - 1
+	r := make([]byte, 0, len(data)*int(frombits)/int(tobits)+1)
+	for _, v := range data {
+		if uint32(v)>>frombits != 0 {
+			return nil, fmt.Errorf("invalid data value %d", v)
+		}
+		acc = acc<<frombits | uint32(v)
+		bits += frombits
+		for bits >= tobits {
+			bits -= tobits
+			r = append(r, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			r = append(r, byte(acc<<(tobits-bits)&maxv))
+		}
+	} else if bits >= frombits || acc<<(tobits-bits)&maxv != 0 {
+		return nil, ErrInvalidPadding
+	}
+	return r, nil
+}
+
+//EncodeSegwit encodes a witness version and program to a segwit address.
+//Version 0 is encoded with bech32 and later versions with bech32m.
+func EncodeSegwit(hrp string, version byte, program []byte) (string, error) {
+	if err := checkProgram(version, program); err != nil {
+		return "", err
+	}
+	conv, err := ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return EncodeVariant(hrp, append([]byte{version}, conv...), segwitVariant(version))
+}
+
+//DecodeSegwit decodes a segwit address and returns its witness version and
+//program. It returns an error if the address doesn't belong to hrp.
+func DecodeSegwit(hrp, addr string) (byte, []byte, error) {
+	h, data, v, err := DecodeVariant(addr)
+	if err != nil {
+		return 0, nil, err
+	}
+	if h != strings.ToLower(hrp) {
+		return 0, nil, fmt.Errorf("hrp %s does not match %s", h, hrp)
+	}
+	if len(data) < 1 {
+		return 0, nil, ErrInvalidProgram
+	}
+	if v != segwitVariant(data[0]) {
+		return 0, nil, ErrInvalidChecksum
+	}
+	program, err := ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := checkProgram(data[0], program); err != nil {
+		return 0, nil, err
+	}
+	return data[0], program, nil
+}
+
+func segwitVariant(version byte) Variant {
+	if version == 0 {
+		return Bech32
+	}
+	return Bech32m
+}
+
+func checkProgram(version byte, program []byte) error {
+	if version > 16 {
+		return ErrInvalidProgram
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return ErrInvalidProgram
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return ErrInvalidProgram
+	}
+	return nil
+}
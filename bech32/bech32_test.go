@@ -0,0 +1,257 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package bech32
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+//test vectors from BIP173.
+var validChecksums = []string{
+	"A12UEL5L",
+	"a12uel5l",
+	"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+	"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+	"11qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqc8247j",
+	"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+	"?1ezyfcl",
+}
+
+var invalidChecksums = []string{
+	" 1nwldj5",
+	"\x7f1axkwrx",
+	"\x801eym55h",
+	"an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx",
+	"pzry9x0s0muk",
+	"1pzry9x0s0muk",
+	"x1b4n0q5v",
+	"li1dgmt3",
+	"de1lg7wt\xff",
+	"A1G7SGD8",
+	"10a06t8",
+	"1qzzfhee",
+	"a12UEL5L",
+}
+
+//test vectors from BIP350.
+var validChecksumsM = []string{
+	"A1LQFN3A",
+	"a1lqfn3a",
+	"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6",
+	"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx",
+	"11llllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllludsr8",
+	"split1checkupstagehandshakeupstreamerranterredcaperredlc445v",
+	"?1v759aa",
+}
+
+var invalidChecksumsM = []string{
+	" 1xj0phk",
+	"\x7f1g6xzxy",
+	"\x801vctc34",
+	"an84characterslonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11d6pts4",
+	"qyrz8wqd2c9m",
+	"1qyrz8wqd2c9m",
+	"y1b0jsk6g",
+	"lt1igcx5c0",
+	"in1muywd",
+	"mm1crxm3i",
+	"au1s5cgom",
+	"M1VUXWEZ",
+	"16plkw9",
+	"1p2gdwpf",
+}
+
+func TestBech32(t *testing.T) {
+	for _, test := range validChecksums {
+		hrp, data, err := Decode(test)
+		if err != nil {
+			t.Errorf("%s: %v", test, err)
+			continue
+		}
+		enc, err := Encode(hrp, data)
+		if err != nil {
+			t.Errorf("%s: %v", test, err)
+			continue
+		}
+		if enc != strings.ToLower(test) {
+			t.Errorf("%s: re-encoded to %s", test, enc)
+		}
+	}
+	for _, test := range invalidChecksums {
+		if _, _, err := Decode(test); err == nil {
+			t.Errorf("%q should be invalid", test)
+		}
+	}
+	for _, test := range validChecksumsM {
+		if _, _, err := Decode(test); err == nil {
+			t.Errorf("%s: bech32m string is decoded as bech32", test)
+		}
+	}
+}
+
+func TestBech32m(t *testing.T) {
+	for _, test := range validChecksumsM {
+		hrp, data, err := DecodeM(test)
+		if err != nil {
+			t.Errorf("%s: %v", test, err)
+			continue
+		}
+		enc, err := EncodeM(hrp, data)
+		if err != nil {
+			t.Errorf("%s: %v", test, err)
+			continue
+		}
+		if enc != strings.ToLower(test) {
+			t.Errorf("%s: re-encoded to %s", test, enc)
+		}
+	}
+	for _, test := range invalidChecksumsM {
+		if _, _, err := DecodeM(test); err == nil {
+			t.Errorf("%q should be invalid", test)
+		}
+	}
+	for _, test := range validChecksums {
+		if _, _, err := DecodeM(test); err == nil {
+			t.Errorf("%s: bech32 string is decoded as bech32m", test)
+		}
+	}
+}
+
+func TestSegwitAddress(t *testing.T) {
+	tests := []struct {
+		hrp          string
+		addr         string
+		scriptPubKey string
+	}{
+		{
+			hrp:          "bc",
+			addr:         "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4",
+			scriptPubKey: "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+		},
+		{
+			hrp:          "tb",
+			addr:         "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7",
+			scriptPubKey: "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262",
+		},
+		{
+			hrp:          "tb",
+			addr:         "tb1qqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesrxh6hy",
+			scriptPubKey: "0020000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433",
+		},
+		{
+			hrp:          "bc",
+			addr:         "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y",
+			scriptPubKey: "5128751e76e8199196d454941c45d1b3a323f1433bd6751e76e8199196d454941c45d1b3a323f1433bd6",
+		},
+		{
+			hrp:          "bc",
+			addr:         "BC1SW50QGDZ25J",
+			scriptPubKey: "6002751e",
+		},
+		{
+			hrp:          "bc",
+			addr:         "bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs",
+			scriptPubKey: "5210751e76e8199196d454941c45d1b3a323",
+		},
+		{
+			hrp:          "tb",
+			addr:         "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c",
+			scriptPubKey: "5120000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433",
+		},
+		{
+			hrp:          "bc",
+			addr:         "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0",
+			scriptPubKey: "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+		},
+	}
+	for _, test := range tests {
+		ver, prog, err := DecodeSegwit(test.hrp, test.addr)
+		if err != nil {
+			t.Errorf("%s: %v", test.addr, err)
+			continue
+		}
+		spk, err := hex.DecodeString(test.scriptPubKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantVer := spk[0]
+		if wantVer != 0 {
+			wantVer -= 0x50
+		}
+		if ver != wantVer || !bytes.Equal(prog, spk[2:]) {
+			t.Errorf("%s: decoded to version %d program %x", test.addr, ver, prog)
+		}
+		enc, err := EncodeSegwit(test.hrp, ver, prog)
+		if err != nil {
+			t.Errorf("%s: %v", test.addr, err)
+			continue
+		}
+		if enc != strings.ToLower(test.addr) {
+			t.Errorf("%s: re-encoded to %s", test.addr, enc)
+		}
+	}
+
+	invalid := []struct {
+		hrp  string
+		addr string
+	}{
+		{"tb", "tc1qw508d6qejxtdg4y5r3zarvary0c5xw7kg3g4ty"},
+		{"bc", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5"},
+		{"bc", "BC13W508D6QEJXTDG4Y5R3ZARVARY0C5XW7KN40WF2"},
+		{"bc", "bc1rw5uspcuh"},
+		{"bc", "bc10w508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kw5rljs90"},
+		{"bc", "BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P"},
+		{"tb", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sL5k7"},
+		{"bc", "bc1zw508d6qejxtdg4y5r3zarvaryvqyzf3du"},
+		{"tb", "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3pjxtptv"},
+		{"bc", "bc1gmk9yu"},
+		//BIP350
+		{"tb", "tc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq5zuyut"},
+		{"bc", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd"},
+		{"tb", "tb1z0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqglt7rf"},
+		{"bc", "BC1S0XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ54WELL"},
+		{"bc", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh"},
+		{"tb", "tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47"},
+		{"bc", "bc1p38j9r5y49hruaue7wxjce0updqjuyyx0kh56v8s25huc6995vvpql3jow4"},
+		{"bc", "BC130XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ7ZWS8R"},
+		{"bc", "bc1pw5dgrnzv"},
+		{"bc", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v8n0nx0muaewav253zgeav"},
+		{"tb", "tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq47Zagq"},
+		{"bc", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v07qwwzcrf"},
+		{"tb", "tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vpggkg4j"},
+	}
+	for _, test := range invalid {
+		if _, _, err := DecodeSegwit(test.hrp, test.addr); err == nil {
+			t.Errorf("%s should be invalid", test.addr)
+		}
+	}
+}
@@ -87,6 +87,11 @@ var (
 	// key is not the expected length.
 	ErrInvalidKeyLen = errors.New("the provided serialized extended key " +
 		"length is invalid")
+
+	// ErrInvalidMasterKey describes an error in which a serialized extended
+	// key with depth 0 has a non-zero parent fingerprint or child index.
+	ErrInvalidMasterKey = errors.New("the extended key at depth 0 must " +
+		"have zero parent fingerprint and child index")
 )
 
 // masterKey is the master key used along with a random seed used to generate
@@ -105,6 +110,7 @@ type ExtendedKey struct {
 	depth     uint16
 	isPrivate bool
 	param     *Params
+	version   *HDVersion // nil means the version of param
 }
 
 // newExtendedKey returns a new instance of an extended key with the given
@@ -166,6 +172,14 @@ func (k *ExtendedKey) ParentFingerprint() uint32 {
 	return binary.BigEndian.Uint32(k.parentFP)
 }
 
+// Fingerprint returns the fingerprint of this extended key, which is the
+// first 4 bytes of the RIPEMD160(SHA256(pubKey)).  It is the parent
+// fingerprint of the children, and the master fingerprint stored in PSBTs
+// and descriptors when the key is the master key.
+func (k *ExtendedKey) Fingerprint() uint32 {
+	return binary.BigEndian.Uint32(AddressBytes(k.pubKeyBytes())[:4])
+}
+
 // Child returns a derived child extended key at the given index.  When this
 // extended key is a private extended key (as determined by the IsPrivate
 // function), a private extended key will be derived.  Otherwise, the derived
@@ -272,7 +286,10 @@ func (k *ExtendedKey) Child(i uint32) (*ExtendedKey, error) {
 		keyNum := new(big.Int).SetBytes(k.key)
 		ilNum.Add(ilNum, keyNum)
 		ilNum.Mod(ilNum, btcec.S256().N)
-		childKey = ilNum.Bytes()
+		//
+		// The sum can be shorter than 32 bytes, so it must be padded with
+		// leading zeros to keep the following derivations correct.
+		childKey = paddedAppend(32, nil, ilNum.Bytes())
 		isPrivate = true
 	} else {
 		// Case #3.
@@ -308,8 +325,10 @@ func (k *ExtendedKey) Child(i uint32) (*ExtendedKey, error) {
 	}
 	parentFP := pubk.AddressBytes()
 	parentFP = parentFP[:4]
-	return newExtendedKey(childKey, childChainCode, parentFP,
-		k.depth+1, i, isPrivate, k.param), nil
+	child := newExtendedKey(childKey, childChainCode, parentFP,
+		k.depth+1, i, isPrivate, k.param)
+	child.version = k.version
+	return child, nil
 }
 
 // Neuter returns a new extended public key from this extended private key.  The
@@ -330,8 +349,32 @@ func (k *ExtendedKey) Neuter() (*ExtendedKey, error) {
 	// key will simply be the pubkey of the current extended private key.
 	//
 	// This is the function N((k,c)) -> (K, c) from [BIP32].
-	return newExtendedKey(k.pubKeyBytes(), k.chainCode, k.parentFP,
-		k.depth, k.childNum, false, k.param), nil
+	pub := newExtendedKey(k.pubKeyBytes(), k.chainCode, k.parentFP,
+		k.depth, k.childNum, false, k.param)
+	pub.version = k.version
+	return pub, nil
+}
+
+// Version returns the version bytes used to serialize the extended key.  It
+// is the version the key was parsed with, or the one of the Params.
+func (k *ExtendedKey) Version() *HDVersion {
+	if k.version == nil {
+		return defaultHDVersion(k.param)
+	}
+	return k.version
+}
+
+// WithVersion returns a copy of the extended key which is serialized with
+// the version bytes v, e.g. to export an xpub as a zpub.  The
+// ErrHDVersionNetwork error is returned if v is for another network than the
+// key.
+func (k *ExtendedKey) WithVersion(v *HDVersion) (*ExtendedKey, error) {
+	if v.Param != k.param {
+		return nil, ErrHDVersionNetwork
+	}
+	c := *k
+	c.version = v
+	return &c, nil
 }
 
 // PubKey converts the extended key to a btcec public key and returns it.
@@ -376,9 +419,9 @@ func (k *ExtendedKey) String() string {
 	//   child num (4) || chain code (32) || key data (33) || checksum (4)
 	serializedBytes := make([]byte, 0, serializedKeyLen+4)
 	if k.isPrivate {
-		serializedBytes = append(serializedBytes, k.param.HDPrivateKeyID...)
+		serializedBytes = append(serializedBytes, k.Version().PrivateID...)
 	} else {
-		serializedBytes = append(serializedBytes, k.param.HDPublicKeyID...)
+		serializedBytes = append(serializedBytes, k.Version().PublicID...)
 	}
 	serializedBytes = append(serializedBytes, depthByte)
 	serializedBytes = append(serializedBytes, k.parentFP...)
@@ -457,8 +500,15 @@ func NewMaster(seed []byte, param *Params) (*ExtendedKey, error) {
 }
 
 // NewKeyFromString returns a new extended key instance from a base58-encoded
-// extended key.
-func NewKeyFromString(key string, param *Params) (*ExtendedKey, error) {
+// extended key.  The version bytes must be the ones of one of params or one of
+// the versions registered for them such as ypub and zpub.  When params is
+// empty, the registered networks are tried, and the first one accepting the
+// version bytes wins.  The script type and network implied by the version
+// bytes are available from the Version function of the returned key.
+func NewKeyFromString(key string, params ...*Params) (*ExtendedKey, error) {
+	if len(params) == 0 {
+		params = RegisteredParams()
+	}
 	// The base58-decoded extended key must consist of a serialized payload
 	// plus an additional 4 bytes for the checksum.
 	payload, err := base58.Decode(key)
@@ -474,15 +524,38 @@ func NewKeyFromString(key string, param *Params) (*ExtendedKey, error) {
 	//   child num (4) || chain code (32) || key data (33) || checksum (4)
 
 	// Deserialize each of the payload fields.
+	var param *Params
+	var version *HDVersion
+	var isPrivateVersion bool
+	for _, p := range params {
+		version, isPrivateVersion, err = findHDVersion(payload[:4], p)
+		if err == nil {
+			param = p
+			break
+		}
+	}
+	if param == nil {
+		return nil, ErrUnknownHDVersion
+	}
 	depth := uint16(payload[4:5][0])
 	parentFP := payload[5:9]
 	childNum := binary.BigEndian.Uint32(payload[9:13])
 	chainCode := payload[13:45]
 	keyData := payload[45:78]
 
+	// A master key has no parent, so both the parent fingerprint and the
+	// child index must be zero.
+	if depth == 0 && (childNum != 0 ||
+		binary.BigEndian.Uint32(parentFP) != 0) {
+		return nil, ErrInvalidMasterKey
+	}
+
 	// The key data is a private key if it starts with 0x00.  Serialized
 	// compressed pubkeys either start with 0x02 or 0x03.
 	isPrivate := keyData[0] == 0x00
+	if isPrivate != isPrivateVersion {
+		return nil, ErrHDVersionMismatch
+	}
 	if isPrivate {
 		// Ensure the private key is valid.  It must be within the range
 		// of the order of the secp256k1 curve and not be 0.
@@ -500,8 +573,10 @@ func NewKeyFromString(key string, param *Params) (*ExtendedKey, error) {
 		}
 	}
 
-	return newExtendedKey(keyData, chainCode, parentFP, depth,
-		childNum, isPrivate, param), nil
+	k := newExtendedKey(keyData, chainCode, parentFP, depth,
+		childNum, isPrivate, param)
+	k.version = version
+	return k, nil
 }
 
 // GenerateSeed returns a cryptographically secure random seed that can be used
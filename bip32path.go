@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//ErrInvalidPath is returned when a derivation path string is malformed.
+var ErrInvalidPath = errors.New("invalid derivation path")
+
+//DerivationPath is a list of child indexes from a master key.
+//Hardened indexes include HardenedKeyStart.
+type DerivationPath []uint32
+
+//ParseDerivationPath parses a path such as "m/84'/0'/0'/1/42".
+//Hardened segments can be marked with ', h or H. The leading "m/" is
+//optional.
+func ParseDerivationPath(s string) (DerivationPath, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "m" || s == "M" {
+		return DerivationPath{}, nil
+	}
+	segs := strings.Split(s, "/")
+	if segs[0] == "m" || segs[0] == "M" {
+		segs = segs[1:]
+	}
+	path := make(DerivationPath, 0, len(segs))
+	for i, seg := range segs {
+		hardened := false
+		switch {
+		case strings.HasSuffix(seg, "'"), strings.HasSuffix(seg, "h"),
+			strings.HasSuffix(seg, "H"):
+			hardened = true
+			seg = seg[:len(seg)-1]
+		}
+		if seg == "" || seg[0] == '+' {
+			return nil, fmt.Errorf("%w: segment %d of %q", ErrInvalidPath, i, s)
+		}
+		n, err := strconv.ParseUint(seg, 10, 32)
+		if err != nil || n >= HardenedKeyStart {
+			return nil, fmt.Errorf("%w: segment %d of %q", ErrInvalidPath, i, s)
+		}
+		if hardened {
+			n += HardenedKeyStart
+		}
+		path = append(path, uint32(n))
+	}
+	return path, nil
+}
+
+//String returns the path in the form "m/84'/0'/0'/1/42".
+func (p DerivationPath) String() string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, i := range p {
+		b.WriteString("/")
+		if i >= HardenedKeyStart {
+			b.WriteString(strconv.FormatUint(uint64(i-HardenedKeyStart), 10))
+			b.WriteString("'")
+		} else {
+			b.WriteString(strconv.FormatUint(uint64(i), 10))
+		}
+	}
+	return b.String()
+}
+
+//DerivationError is returned by DerivePath and tells which segment of the
+//path failed.
+type DerivationError struct {
+	//Depth is the position of the failed segment in the path.
+	Depth int
+	//Index is the child index of the failed segment.
+	Index uint32
+	//Err is the error returned by Child, such as ErrInvalidChild or
+	//ErrDeriveHardFromPublic.
+	Err error
+}
+
+func (e *DerivationError) Error() string {
+	return fmt.Sprintf("failed to derive segment %d (%s) of the path: %v",
+		e.Depth, DerivationPath{e.Index}.String()[2:], e.Err)
+}
+
+//Unwrap returns the underlying error.
+func (e *DerivationError) Unwrap() error {
+	return e.Err
+}
+
+//DerivePath derives the descendant key along path from k.
+//It returns DerivationError if any of the segment cannot be derived; no
+//segment is skipped.
+func (k *ExtendedKey) DerivePath(path DerivationPath) (*ExtendedKey, error) {
+	key := k
+	for d, i := range path {
+		child, err := key.Child(i)
+		if err != nil {
+			return nil, &DerivationError{Depth: d, Index: i, Err: err}
+		}
+		key = child
+	}
+	return key, nil
+}
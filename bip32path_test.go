@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseDerivationPath(t *testing.T) {
+	tests := []struct {
+		in   string
+		path DerivationPath
+		out  string
+	}{
+		{"m", DerivationPath{}, "m"},
+		{"m/84'/0'/0'/1/42", DerivationPath{HardenedKeyStart + 84, HardenedKeyStart, HardenedKeyStart, 1, 42}, "m/84'/0'/0'/1/42"},
+		{"m/44h/1H/2", DerivationPath{HardenedKeyStart + 44, HardenedKeyStart + 1, 2}, "m/44'/1'/2"},
+		{"0/2147483647'", DerivationPath{0, HardenedKeyStart + 2147483647}, "m/0/2147483647'"},
+	}
+	for _, test := range tests {
+		p, err := ParseDerivationPath(test.in)
+		if err != nil {
+			t.Error(test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(p, test.path) {
+			t.Error(test.in, "parsed to", p)
+		}
+		if p.String() != test.out {
+			t.Error(test.in, "formatted to", p.String())
+		}
+	}
+
+	invalid := []string{
+		"m/",
+		"m//1",
+		"m/1/",
+		"m/2147483648",
+		"m/2147483648'",
+		"m/-1",
+		"m/+1",
+		"m/1''",
+		"m/x",
+		"n/1",
+	}
+	for _, in := range invalid {
+		if _, err := ParseDerivationPath(in); !errors.Is(err, ErrInvalidPath) {
+			t.Error(in, "should be invalid", err)
+		}
+	}
+}
+
+func TestDerivePath(t *testing.T) {
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := NewMaster(seed, BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := ParseDerivationPath("m/0H/1/2H/2/1000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := master.DerivePath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76"
+	if k.String() != want {
+		t.Error("invalid derived key", k)
+	}
+
+	pub, err := master.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err = ParseDerivationPath("m/1/2'/3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pub.DerivePath(path)
+	derr, ok := err.(*DerivationError)
+	if !ok {
+		t.Fatal("DerivationError expected", err)
+	}
+	if derr.Depth != 1 || derr.Index != HardenedKeyStart+2 || derr.Err != ErrDeriveHardFromPublic {
+		t.Error("invalid derivation error", derr)
+	}
+	if !errors.Is(err, ErrDeriveHardFromPublic) {
+		t.Error("DerivationError must unwrap to ErrDeriveHardFromPublic")
+	}
+}
@@ -0,0 +1,355 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+// References:
+//   [BIP38]: Passphrase-protected private key
+//   https://github.com/bitcoin/bips/blob/master/bip-0038.mediawiki
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/bitgoin/address/base58"
+	"github.com/bitgoin/address/btcec"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+//prefixes and flags of BIP38.
+const (
+	bip38NonECPrefix = 0x42
+	bip38ECPrefix    = 0x43
+
+	bip38FlagNonEC      = 0xc0
+	bip38FlagCompressed = 0x20
+	bip38FlagLotSeq     = 0x04
+
+	//MaxBIP38Lot is the maximum lot number of intermediate codes.
+	MaxBIP38Lot = 1048575
+	//MaxBIP38Sequence is the maximum sequence number of intermediate codes.
+	MaxBIP38Sequence = 4095
+)
+
+//magic bytes of intermediate codes, with and without lot and sequence numbers.
+var (
+	intermediateMagicLotSeq = []byte{0x2c, 0xe9, 0xb3, 0xe1, 0xff, 0x39, 0xe2, 0x51}
+	intermediateMagic       = []byte{0x2c, 0xe9, 0xb3, 0xe1, 0xff, 0x39, 0xe2, 0x53}
+)
+
+var (
+	//ErrInvalidBIP38 is returned when the string is not BIP38 encrypted key.
+	ErrInvalidBIP38 = errors.New("invalid BIP38 encrypted key")
+
+	//ErrBIP38Passphrase is returned when the address hash of the decrypted
+	//key doesn't match, which means the passphrase is wrong.
+	ErrBIP38Passphrase = errors.New("wrong passphrase for BIP38 encrypted key")
+
+	//ErrInvalidIntermediateCode is returned when the string is not
+	//an intermediate code.
+	ErrInvalidIntermediateCode = errors.New("invalid intermediate code")
+
+	//ErrInvalidLotSequence is returned when the lot or sequence number is out
+	//of range.
+	ErrInvalidLotSequence = errors.New("lot or sequence number is out of range")
+)
+
+func doubleSHA256(b []byte) []byte {
+	h := sha256.Sum256(b)
+	h = sha256.Sum256(h[:])
+	return h[:]
+}
+
+//addressHash returns the first 4 bytes of double sha256 of the address.
+func addressHash(addr string) []byte {
+	return doubleSHA256([]byte(addr))[:4]
+}
+
+//xorBytes returns a xor b.
+func xorBytes(a, b []byte) []byte {
+	r := make([]byte, len(a))
+	for i := range a {
+		r[i] = a[i] ^ b[i]
+	}
+	return r
+}
+
+//aesEncrypt encrypts one 16 bytes block by AES-256 with key.
+func aesEncrypt(key, block []byte) []byte {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	r := make([]byte, aes.BlockSize)
+	c.Encrypt(r, block)
+	return r
+}
+
+//aesDecrypt decrypts one 16 bytes block by AES-256 with key.
+func aesDecrypt(key, block []byte) []byte {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	r := make([]byte, aes.BlockSize)
+	c.Decrypt(r, block)
+	return r
+}
+
+//EncryptBIP38 encrypts the private key with passphrase and returns BIP38
+//encrypted key (6P...) without EC multiplication.
+func (priv *PrivateKey) EncryptBIP38(passphrase string) (string, error) {
+	flag := byte(bip38FlagNonEC)
+	if priv.PublicKey.isCompressed {
+		flag |= bip38FlagCompressed
+	}
+	ahash := addressHash(priv.PublicKey.Address())
+	derived, err := scrypt.Key([]byte(norm.NFC.String(passphrase)), ahash,
+		16384, 8, 8, 64)
+	if err != nil {
+		return "", err
+	}
+	pb := paddedAppend(32, nil, priv.D.Bytes())
+	half1 := aesEncrypt(derived[32:], xorBytes(pb[:16], derived[:16]))
+	half2 := aesEncrypt(derived[32:], xorBytes(pb[16:], derived[16:32]))
+
+	r := []byte{0x01, bip38NonECPrefix, flag}
+	r = append(r, ahash...)
+	r = append(r, half1...)
+	r = append(r, half2...)
+	return base58.Encode(r), nil
+}
+
+//DecryptBIP38 decrypts BIP38 encrypted key with passphrase.
+//Both keys encrypted with and without EC multiplication are supported.
+func DecryptBIP38(s, passphrase string, param *Params) (*PrivateKey, error) {
+	b, err := base58.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != 39 || b[0] != 0x01 {
+		return nil, ErrInvalidBIP38
+	}
+	passphrase = norm.NFC.String(passphrase)
+	flag := b[2]
+	ahash := b[3:7]
+	var priv *PrivateKey
+	switch b[1] {
+	case bip38NonECPrefix:
+		if flag&^bip38FlagCompressed != bip38FlagNonEC {
+			return nil, ErrInvalidBIP38
+		}
+		priv, err = decryptBIP38NonEC(b, passphrase, param)
+	case bip38ECPrefix:
+		if flag&^(bip38FlagCompressed|bip38FlagLotSeq) != 0 {
+			return nil, ErrInvalidBIP38
+		}
+		priv, err = decryptBIP38EC(b, passphrase, param)
+	default:
+		return nil, ErrInvalidBIP38
+	}
+	if err != nil {
+		return nil, err
+	}
+	priv.PublicKey.isCompressed = flag&bip38FlagCompressed != 0
+	if !bytes.Equal(addressHash(priv.PublicKey.Address()), ahash) {
+		return nil, ErrBIP38Passphrase
+	}
+	return priv, nil
+}
+
+func decryptBIP38NonEC(b []byte, passphrase string, param *Params) (*PrivateKey, error) {
+	derived, err := scrypt.Key([]byte(passphrase), b[3:7], 16384, 8, 8, 64)
+	if err != nil {
+		return nil, err
+	}
+	half1 := xorBytes(aesDecrypt(derived[32:], b[7:23]), derived[:16])
+	half2 := xorBytes(aesDecrypt(derived[32:], b[23:39]), derived[16:32])
+	pb := append(half1, half2...)
+	d := new(big.Int).SetBytes(pb)
+	if d.Sign() == 0 || d.Cmp(secp256k1.N) >= 0 {
+		return nil, ErrBIP38Passphrase
+	}
+	return NewPrivateKey(pb, param), nil
+}
+
+func decryptBIP38EC(b []byte, passphrase string, param *Params) (*PrivateKey, error) {
+	lotSeq := b[2]&bip38FlagLotSeq != 0
+	ownerEntropy := b[7:15]
+	passfactor, err := bip38Passfactor(passphrase, ownerEntropy, lotSeq)
+	if err != nil {
+		return nil, err
+	}
+	x, y := secp256k1.ScalarBaseMult(passfactor)
+	passpoint := (&btcec.PublicKey{Curve: secp256k1, X: x, Y: y}).SerializeCompressed()
+	derived, err := scrypt.Key(passpoint, b[3:15], 1024, 1, 1, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	//encryptedpart2 includes the latter half of encryptedpart1.
+	dec2 := xorBytes(aesDecrypt(derived[32:], b[23:39]), derived[16:32])
+	part1 := append(append([]byte{}, b[15:23]...), dec2[:8]...)
+	dec1 := xorBytes(aesDecrypt(derived[32:], part1), derived[:16])
+	seedb := append(dec1, dec2[8:]...)
+
+	factorb := new(big.Int).SetBytes(doubleSHA256(seedb))
+	d := new(big.Int).SetBytes(passfactor)
+	d.Mul(d, factorb)
+	d.Mod(d, secp256k1.N)
+	if d.Sign() == 0 {
+		return nil, ErrBIP38Passphrase
+	}
+	return NewPrivateKey(paddedAppend(32, nil, d.Bytes()), param), nil
+}
+
+//bip38Passfactor returns passfactor of EC multiplication mode.
+func bip38Passfactor(passphrase string, ownerEntropy []byte, lotSeq bool) ([]byte, error) {
+	salt := ownerEntropy
+	if lotSeq {
+		salt = ownerEntropy[:4]
+	}
+	prefactor, err := scrypt.Key([]byte(passphrase), salt, 16384, 8, 8, 32)
+	if err != nil {
+		return nil, err
+	}
+	if !lotSeq {
+		return prefactor, nil
+	}
+	return doubleSHA256(append(prefactor, ownerEntropy...)), nil
+}
+
+//NewIntermediateCode returns an intermediate code (passphrase...) of
+//passphrase, with which anyone can create BIP38 encrypted keys by
+//EncryptBIP38FromIntermediate without knowing the passphrase.
+func NewIntermediateCode(passphrase string) (string, error) {
+	ownerSalt := make([]byte, 8)
+	if _, err := rand.Read(ownerSalt); err != nil {
+		return "", err
+	}
+	return intermediateCode(passphrase, ownerSalt, false)
+}
+
+//NewIntermediateCodeWithLot returns an intermediate code of passphrase
+//which includes lot and sequence numbers in encrypted keys.
+func NewIntermediateCodeWithLot(passphrase string, lot, sequence uint32) (string, error) {
+	if lot > MaxBIP38Lot || sequence > MaxBIP38Sequence {
+		return "", ErrInvalidLotSequence
+	}
+	ownerEntropy := make([]byte, 8)
+	if _, err := rand.Read(ownerEntropy[:4]); err != nil {
+		return "", err
+	}
+	binary.BigEndian.PutUint32(ownerEntropy[4:], lot*4096+sequence)
+	return intermediateCode(passphrase, ownerEntropy, true)
+}
+
+func intermediateCode(passphrase string, ownerEntropy []byte, lotSeq bool) (string, error) {
+	passfactor, err := bip38Passfactor(norm.NFC.String(passphrase),
+		ownerEntropy, lotSeq)
+	if err != nil {
+		return "", err
+	}
+	x, y := secp256k1.ScalarBaseMult(passfactor)
+	passpoint := (&btcec.PublicKey{Curve: secp256k1, X: x, Y: y}).SerializeCompressed()
+	magic := intermediateMagic
+	if lotSeq {
+		magic = intermediateMagicLotSeq
+	}
+	r := append(append([]byte{}, magic...), ownerEntropy...)
+	r = append(r, passpoint...)
+	return base58.Encode(r), nil
+}
+
+//EncryptBIP38FromIntermediate creates a new key from the intermediate code
+//and returns its BIP38 encrypted key and address.
+//The private key can only be decrypted with the passphrase of the
+//intermediate code.
+func EncryptBIP38FromIntermediate(code string, compressed bool, param *Params) (string, string, error) {
+	seedb := make([]byte, 24)
+	if _, err := rand.Read(seedb); err != nil {
+		return "", "", err
+	}
+	return encryptBIP38FromIntermediate(code, seedb, compressed, param)
+}
+
+func encryptBIP38FromIntermediate(code string, seedb []byte, compressed bool, param *Params) (string, string, error) {
+	b, err := base58.Decode(code)
+	if err != nil {
+		return "", "", err
+	}
+	if len(b) != 49 {
+		return "", "", ErrInvalidIntermediateCode
+	}
+	flag := byte(0)
+	switch {
+	case bytes.Equal(b[:8], intermediateMagicLotSeq):
+		flag |= bip38FlagLotSeq
+	case bytes.Equal(b[:8], intermediateMagic):
+	default:
+		return "", "", ErrInvalidIntermediateCode
+	}
+	if compressed {
+		flag |= bip38FlagCompressed
+	}
+	ownerEntropy := b[8:16]
+	passpoint, err := btcec.ParsePubKey(b[16:], secp256k1)
+	if err != nil {
+		return "", "", err
+	}
+
+	factorb := doubleSHA256(seedb)
+	x, y := secp256k1.ScalarMult(passpoint.X, passpoint.Y, factorb)
+	pub := &PublicKey{
+		PublicKey:    &btcec.PublicKey{Curve: secp256k1, X: x, Y: y},
+		isCompressed: compressed,
+		param:        param,
+	}
+	addr := pub.Address()
+	ahash := addressHash(addr)
+	salt := append(append([]byte{}, ahash...), ownerEntropy...)
+	derived, err := scrypt.Key(b[16:], salt, 1024, 1, 1, 64)
+	if err != nil {
+		return "", "", err
+	}
+	part1 := aesEncrypt(derived[32:], xorBytes(seedb[:16], derived[:16]))
+	part2 := aesEncrypt(derived[32:],
+		xorBytes(append(part1[8:], seedb[16:]...), derived[16:32]))
+
+	r := []byte{0x01, bip38ECPrefix, flag}
+	r = append(r, salt...)
+	r = append(r, part1[:8]...)
+	r = append(r, part2...)
+	return base58.Encode(r), addr, nil
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitgoin/address/base58"
+)
+
+func TestBIP38NonEC(t *testing.T) {
+	tests := []struct {
+		passphrase string
+		encrypted  string
+		wif        string
+	}{
+		//no compression
+		{
+			passphrase: "TestingOneTwoThree",
+			encrypted:  "6PRVWUbkzzsbcVac2qwfssoUJAN1Xhrg6bNk8J7Nzm5H7kxEbn2Nh2ZoGg",
+			wif:        "5KN7MzqK5wt2TP1fQCYyHBtDrXdJuXbUzm4A9rKAteGu3Qi5CVR",
+		},
+		{
+			passphrase: "Satoshi",
+			encrypted:  "6PRNFFkZc2NZ6dJqFfhRoFNMR9Lnyj7dYGrzdgXXVMXcxoKTePPX1dWByq",
+			wif:        "5HtasZ6ofTHP6HCwTqTkLDuLQisYPah7aUnSKfC7h4hMUVw2gi5",
+		},
+		//"ϓ␀𐐀💩", which is normalised to "\u03d3\u0000\U00010400\U0001f4a9" by NFC.
+		{
+			passphrase: "\u03d2\u0301\u0000\U00010400\U0001f4a9",
+			encrypted:  "6PRW5o9FLp4gJDDVqJQKJFTpMvdsSGJxMYHtHaQBF3ooa8mwD69bapcDQn",
+			wif:        "5Jajm8eQ22H3pGWLEVCXyvND8dQZhiQhoLJNKjYXk9roUFTMSZ4",
+		},
+		//compression
+		{
+			passphrase: "TestingOneTwoThree",
+			encrypted:  "6PYNKZ1EAgYgmQfmNVamxyXVWHzK5s6DGhwP4J5o44cvXdoY7sRzhtpUeo",
+			wif:        "L44B5gGEpqEDRS9vVPz7QT35jcBG2r3CZwSwQ4fCewXAhAhqGVpP",
+		},
+		{
+			passphrase: "Satoshi",
+			encrypted:  "6PYLtMnXvfG3oJde97zRyLYFZCYizPU5T3LwgdYJz1fRhh16bU7u6PPmY7",
+			wif:        "KwYgW8gcxj1JWJXhPSu4Fqwzfhp5Yfi42mdYmMa4XqK7NJxXUSK7",
+		},
+	}
+	for i, test := range tests {
+		key, err := FromWIF(test.wif, BitcoinMain)
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		enc, err := key.EncryptBIP38(test.passphrase)
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if enc != test.encrypted {
+			t.Error(i, "invalid encrypted key", enc)
+		}
+		dec, err := DecryptBIP38(test.encrypted, test.passphrase, BitcoinMain)
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if wif := dec.WIFAddress(); wif != test.wif {
+			t.Error(i, "invalid decrypted key", wif)
+		}
+		if _, err := DecryptBIP38(test.encrypted, "wrong", BitcoinMain); err != ErrBIP38Passphrase {
+			t.Error(i, "wrong passphrase should be detected", err)
+		}
+	}
+}
+
+func TestBIP38EC(t *testing.T) {
+	tests := []struct {
+		passphrase string
+		code       string
+		encrypted  string
+		address    string
+		wif        string
+	}{
+		//no compression, no lot and sequence numbers
+		{
+			passphrase: "TestingOneTwoThree",
+			code:       "passphrasepxFy57B9v8HtUsszJYKReoNDV6VHjUSGt8EVJmux9n1J3Ltf1gRxyDGXqnf9qm",
+			encrypted:  "6PfQu77ygVyJLZjfvMLyhLMQbYnu5uguoJJ4kMCLqWwPEdfpwANVS76gTX",
+			address:    "1PE6TQi6HTVNz5DLwB1LcpMBALubfuN2z2",
+			wif:        "5K4caxezwjGCGfnoPTZ8tMcJBLB7Jvyjv4xxeacadhq8nLisLR2",
+		},
+		{
+			passphrase: "Satoshi",
+			code:       "passphraseoRDGAXTWzbp72eVbtUDdn1rwpgPUGjNZEc6CGBo8i5EC1FPW8wcnLdq4ThKzAS",
+			encrypted:  "6PfLGnQs6VZnrNpmVKfjotbnQuaJK4KZoPFrAjx1JMJUa1Ft8gnf5WxfKd",
+			address:    "1CqzrtZC6mXSAhoxtFwVjz8LtwLJjDYU3V",
+			wif:        "5KJ51SgxWaAYR13zd9ReMhJpwrcX47xTJh2D3fGPG9CM8vkv5sH",
+		},
+		//no compression, lot and sequence numbers
+		{
+			passphrase: "MOLON LABE",
+			code:       "passphraseaB8feaLQDENqCgr4gKZpmf4VoaT6qdjJNJiv7fsKvjqavcJxvuR1hy25aTu5sX",
+			encrypted:  "6PgNBNNzDkKdhkT6uJntUXwwzQV8Rr2tZcbkDcuC9DZRsS6AtHts4Ypo1j",
+			address:    "1Jscj8ALrYu2y9TD8NrpvDBugPedmbj4Yh",
+			wif:        "5JLdxTtcTHcfYcmJsNVy1v2PMDx432JPoYcBTVVRHpPaxUrdtf8",
+		},
+	}
+	for i, test := range tests {
+		//the intermediate code is determined by its owner entropy.
+		b, err := base58.Decode(test.code)
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		code, err := intermediateCode(test.passphrase, b[8:16],
+			b[7] == intermediateMagicLotSeq[7])
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if code != test.code {
+			t.Error(i, "invalid intermediate code", code)
+		}
+
+		dec, err := DecryptBIP38(test.encrypted, test.passphrase, BitcoinMain)
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if wif := dec.WIFAddress(); wif != test.wif {
+			t.Error(i, "invalid decrypted key", wif)
+		}
+		if adr := dec.PublicKey.Address(); adr != test.address {
+			t.Error(i, "invalid address", adr)
+		}
+		if _, err := DecryptBIP38(test.encrypted, "wrong", BitcoinMain); err != ErrBIP38Passphrase {
+			t.Error(i, "wrong passphrase should be detected", err)
+		}
+	}
+}
+
+func TestBIP38Intermediate(t *testing.T) {
+	code, err := NewIntermediateCodeWithLot("passphrase", 263183, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(code, "passphrase") {
+		t.Error("invalid intermediate code", code)
+	}
+	for _, compressed := range []bool{false, true} {
+		enc, adr, err := EncryptBIP38FromIntermediate(code, compressed, BitcoinTest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(enc, "6P") {
+			t.Error("invalid encrypted key", enc)
+		}
+		key, err := DecryptBIP38(enc, "passphrase", BitcoinTest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key.PublicKey.Address() != adr {
+			t.Error("address unmatched", compressed)
+		}
+	}
+	if _, err := NewIntermediateCodeWithLot("passphrase", MaxBIP38Lot+1, 0); err != ErrInvalidLotSequence {
+		t.Error("lot number should be checked", err)
+	}
+	if _, _, err := EncryptBIP38FromIntermediate("6PRVWUbkzzsbcVac2qwfssoUJAN1Xhrg6bNk8J7Nzm5H7kxEbn2Nh2ZoGg", false, BitcoinMain); err != ErrInvalidIntermediateCode {
+		t.Error("invalid intermediate code should be rejected", err)
+	}
+}
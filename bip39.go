@@ -30,11 +30,9 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
-	"strings"
-
-	"sort"
 
 	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
 )
 
 // Some bitwise operands for working with big.Ints
@@ -58,10 +56,18 @@ func NewEntropy(bitSize int) ([]byte, error) {
 	return entropy, err
 }
 
-// NewMnemonic will return a string consisting of the mnemonic words for
-// the given entropy.
+// NewMnemonic will return a string consisting of the English mnemonic words
+// for the given entropy.
 // If the provide entropy is invalid, an error will be returned.
 func NewMnemonic(entropy []byte) (string, error) {
+	return NewMnemonicWithWordlist(entropy, English)
+}
+
+// NewMnemonicWithWordlist will return a string consisting of the mnemonic
+// words of wordlist for the given entropy, joined by the separator of the
+// wordlist.
+// If the provide entropy is invalid, an error will be returned.
+func NewMnemonicWithWordlist(entropy []byte, wordlist *Wordlist) (string, error) {
 	// Compute some lengths for convenience
 	entropyBitLength := len(entropy) * 8
 	checksumBitLength := entropyBitLength / 32
@@ -98,86 +104,95 @@ func NewMnemonic(entropy []byte) (string, error) {
 		wordBytes := padByteSlice(word.Bytes(), 2)
 
 		// Convert bytes to an index and add that word to the list
-		words[i] = wordlist[binary.BigEndian.Uint16(wordBytes)]
+		words[i] = wordlist.Word(int(binary.BigEndian.Uint16(wordBytes)))
 	}
 
-	return strings.Join(words, " "), nil
+	return wordlist.Join(words), nil
 }
 
-// MnemonicToByteArray takes a mnemonic string and turns it into a byte array
-// suitable for creating another mnemonic.
-// An error is returned if the mnemonic is invalid.
-// FIXME
-// This does not work for all values in
-// the test vectors.  Namely
-// Vectors 0, 4, and 8.
-// This is not really important because BIP39 doesnt really define a conversion
-// from string to bytes.
-func MnemonicToByteArray(mnemonic string) ([]byte, error) {
-	if !IsMnemonicValid(mnemonic) {
-		return nil, fmt.Errorf("Invalid mnemonic")
-	}
-	mnemonicSlice := strings.Split(mnemonic, " ")
+// UnknownWordError describes an error in which a word of the mnemonic is not
+// in the word list.
+type UnknownWordError struct {
+	// Position is the zero-based position of the word in the mnemonic.
+	Position int
+	// Word is the unknown word.
+	Word string
+}
 
-	bitSize := len(mnemonicSlice) * 11
-	err := validateEntropyWithChecksumBitSize(bitSize)
-	if err != nil {
-		return nil, err
+func (e *UnknownWordError) Error() string {
+	return fmt.Sprintf("unknown word %q at position %d", e.Word, e.Position)
+}
+
+var (
+	// ErrInvalidWordCount describes an error in which the number of words
+	// of the mnemonic is not 12, 15, 18, 21 or 24.
+	ErrInvalidWordCount = errors.New("number of mnemonic words must be " +
+		"12, 15, 18, 21 or 24")
+
+	// ErrMnemonicChecksum describes an error in which the checksum encoded
+	// in the mnemonic does not match the entropy.
+	ErrMnemonicChecksum = errors.New("invalid mnemonic checksum")
+)
+
+// MnemonicToEntropy takes a mnemonic string and returns the entropy encoded
+// in it, which is the input of NewMnemonic.
+// The word list is detected from the words.
+// An error of type *UnknownWordError, ErrInvalidWordCount or
+// ErrMnemonicChecksum is returned if the mnemonic is invalid.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := splitMnemonic(mnemonic)
+	bitSize := len(words) * 11
+	if validateEntropyWithChecksumBitSize(bitSize) != nil {
+		return nil, ErrInvalidWordCount
 	}
-	checksumSize := bitSize % 32
-
-	b := big.NewInt(0)
-	modulo := big.NewInt(2048)
-	for _, v := range mnemonicSlice {
-		index := sort.SearchStrings(wordlist, v)
-		if index >= len(wordlist) || wordlist[index] != v {
-			return nil, fmt.Errorf("Word `%v` not found in reverse map", v)
-		}
-		add := big.NewInt(int64(index))
-		b = b.Mul(b, modulo)
-		b = b.Add(b, add)
+	wordlist, err := DetectWordlist(mnemonic)
+	if err != nil {
+		return nil, unknownWord(words)
 	}
-	hex := b.Bytes()
-	checksumModulo := big.NewInt(0).Exp(big.NewInt(2), big.NewInt(int64(checksumSize)), nil)
-	entropy, _ := big.NewInt(0).DivMod(b, checksumModulo, big.NewInt(0))
-
-	entropyHex := entropy.Bytes()
-
-	byteSize := bitSize/8 + 1
-	if len(hex) != byteSize {
-		tmp := make([]byte, byteSize)
-		diff := byteSize - len(hex)
-		for i := 0; i < len(hex); i++ {
-			tmp[i+diff] = hex[i]
-		}
-		hex = tmp
+
+	// Concatenate 11 bits of each word index.
+	b := new(big.Int)
+	for _, v := range words {
+		index, _ := wordlist.Index(v)
+		b.Lsh(b, 11)
+		b.Or(b, big.NewInt(int64(index)))
 	}
 
-	validationHex := addChecksum(entropyHex)
-	if len(validationHex) != byteSize {
-		tmp2 := make([]byte, byteSize)
-		diff2 := byteSize - len(validationHex)
-		for i := 0; i < len(validationHex); i++ {
-			tmp2[i+diff2] = validationHex[i]
-		}
-		validationHex = tmp2
+	// Split the last checksum bits from the entropy.
+	checksumSize := uint(bitSize / 33)
+	checksum := new(big.Int).And(b, big.NewInt(1<<checksumSize-1))
+	b.Rsh(b, checksumSize)
+	entropy := padByteSlice(b.Bytes(), (bitSize-int(checksumSize))/8)
+
+	hash := sha256.Sum256(entropy)
+	if uint64(hash[0]>>(8-checksumSize)) != checksum.Uint64() {
+		return nil, ErrMnemonicChecksum
 	}
+	return entropy, nil
+}
 
-	if len(hex) != len(validationHex) {
-		panic("[]byte len mismatch - it shouldn't happen")
+// unknownWord returns the first word which is not in the word list including
+// the first word, or in English if no list includes it.
+func unknownWord(words []string) error {
+	wordlist := English
+	for _, wl := range Wordlists {
+		if _, ok := wl.Index(words[0]); ok {
+			wordlist = wl
+			break
+		}
 	}
-	for i := range validationHex {
-		if hex[i] != validationHex[i] {
-			return nil, fmt.Errorf("Invalid byte at position %v", i)
+	for i, w := range words {
+		if _, ok := wordlist.Index(w); !ok {
+			return &UnknownWordError{Position: i, Word: w}
 		}
 	}
-	return hex, nil
+	return ErrUnknownWordlist
 }
 
 // NewSeedWithErrorChecking creates a hashed seed output given the mnemonic string and a password.
-// An error is returned if the mnemonic is not convertible to a byte array.
+// An error is returned if the mnemonic is invalid, see MnemonicToEntropy.
 func NewSeedWithErrorChecking(mnemonic string, password string) ([]byte, error) {
-	_, err := MnemonicToByteArray(mnemonic)
+	_, err := MnemonicToEntropy(mnemonic)
 	if err != nil {
 		return nil, err
 	}
@@ -185,8 +200,11 @@ func NewSeedWithErrorChecking(mnemonic string, password string) ([]byte, error)
 }
 
 // NewSeed creates a hashed seed output given a provided string and password.
+// Both of them are NFKD normalised as [BIP39] requires.
 // No checking is performed to validate that the string provided is a valid mnemonic.
 func NewSeed(mnemonic string, password string) []byte {
+	mnemonic = norm.NFKD.String(mnemonic)
+	password = norm.NFKD.String(password)
 	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+password), 2048, 64, sha512.New)
 }
 
@@ -241,27 +259,10 @@ func validateEntropyWithChecksumBitSize(bitSize int) error {
 }
 
 // IsMnemonicValid attempts to verify that the provided mnemonic is valid.
-// Validity is determined by both the number of words being appropriate,
-// and that all the words in the mnemonic are present in the word list.
+// Validity is determined by the number of words being appropriate, all the
+// words in the mnemonic being present in one of the word lists and the
+// checksum matching the entropy.
 func IsMnemonicValid(mnemonic string) bool {
-	// Create a list of all the words in the mnemonic sentence
-	words := strings.Fields(mnemonic)
-
-	//Get num of words
-	numOfWords := len(words)
-
-	// The number of words should be 12, 15, 18, 21 or 24
-	if numOfWords%3 != 0 || numOfWords < 12 || numOfWords > 24 {
-		return false
-	}
-
-	// Check if all words belong in the wordlist
-	for i := 0; i < numOfWords; i++ {
-		ind := sort.SearchStrings(wordlist, words[i])
-		if ind >= len(wordlist) || wordlist[ind] != words[i] {
-			return false
-		}
-	}
-
-	return true
+	_, err := MnemonicToEntropy(mnemonic)
+	return err == nil
 }
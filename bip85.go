@@ -0,0 +1,276 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+// References:
+//   [BIP85]: Deterministic Entropy From BIP32 Keychains
+//   https://github.com/bitcoin/bips/blob/master/bip-0085.mediawiki
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"math/bits"
+
+	"github.com/bitgoin/address/btcec"
+	"golang.org/x/crypto/sha3"
+)
+
+//BIP85Purpose is the purpose of the derivation path of BIP85.
+const BIP85Purpose = 83696968
+
+//BIP85 applications, which are the second index of the derivation path.
+const (
+	BIP85AppBIP39  = 39
+	BIP85AppWIF    = 2
+	BIP85AppXPRV   = 32
+	BIP85AppHex    = 128169
+	BIP85AppBase64 = 707764
+	BIP85AppBase85 = 707785
+	BIP85AppDice   = 89101
+)
+
+//ErrInvalidBIP85Param is returned when a parameter of a BIP85 application,
+//such as the number of words or the length of a password, is out of range.
+var ErrInvalidBIP85Param = errors.New("invalid BIP85 parameter")
+
+//bip85Languages is the language codes of wordlists.
+var bip85Languages = map[*Wordlist]uint32{
+	English:            0,
+	Japanese:           1,
+	Korean:             2,
+	Spanish:            3,
+	ChineseSimplified:  4,
+	ChineseTraditional: 5,
+	French:             6,
+	Italian:            7,
+	Czech:              8,
+	Portuguese:         9,
+}
+
+//base85Chars is the alphabet of RFC1924 used by BIP85 passwords.
+const base85Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+	"abcdefghijklmnopqrstuvwxyz!#$%&()*+-;<=>?@^_`{|}~"
+
+//hardened returns the path of hardened indexes. ErrInvalidBIP85Param is
+//returned if any of them is not less than HardenedKeyStart, which would wrap
+//around to a non-hardened index.
+func hardened(indexes ...uint32) (DerivationPath, error) {
+	path := make(DerivationPath, len(indexes))
+	for i, n := range indexes {
+		if n >= HardenedKeyStart {
+			return nil, fmt.Errorf("%w: index %d is out of range",
+				ErrInvalidBIP85Param, n)
+		}
+		path[i] = n + HardenedKeyStart
+	}
+	return path, nil
+}
+
+//BIP85Entropy returns 64 bytes of entropy derived from the private key at
+//m/83696968'/path, where k should be the master key and path is usually
+//hardened. Use the methods for the applications rather than this.
+func (k *ExtendedKey) BIP85Entropy(path DerivationPath) ([]byte, error) {
+	if !k.IsPrivate() {
+		return nil, ErrNotPrivExtKey
+	}
+	child, err := k.DerivePath(append(DerivationPath{HardenedKeyStart + BIP85Purpose},
+		path...))
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha512.New, []byte("bip-entropy-from-k"))
+	mac.Write(paddedAppend(32, nil, child.key))
+	return mac.Sum(nil), nil
+}
+
+//bip85Entropy returns the entropy at the path of hardened indexes.
+func (k *ExtendedKey) bip85Entropy(indexes ...uint32) ([]byte, error) {
+	path, err := hardened(indexes...)
+	if err != nil {
+		return nil, err
+	}
+	return k.BIP85Entropy(path)
+}
+
+//NewBIP85DRNG returns the BIP85-DRNG-SHAKE256 stream seeded with the 64
+//bytes entropy, which generates arbitrary length of random bytes.
+func NewBIP85DRNG(entropy []byte) io.Reader {
+	h := sha3.NewShake256()
+	h.Write(entropy)
+	return h
+}
+
+//BIP85Mnemonic returns the BIP39 mnemonic of words words (12, 15, 18, 21
+//or 24) in the language of wordlist at m/83696968'/39'/language'/words'/index'.
+func (k *ExtendedKey) BIP85Mnemonic(wordlist *Wordlist, words int, index uint32) (string, error) {
+	lang, ok := bip85Languages[wordlist]
+	if !ok {
+		return "", fmt.Errorf("%w: no language code for wordlist", ErrInvalidBIP85Param)
+	}
+	if words < 12 || words > 24 || words%3 != 0 {
+		return "", fmt.Errorf("%w: %d words", ErrInvalidBIP85Param, words)
+	}
+	e, err := k.bip85Entropy(BIP85AppBIP39, lang, uint32(words), index)
+	if err != nil {
+		return "", err
+	}
+	return NewMnemonicWithWordlist(e[:words*4/3], wordlist)
+}
+
+//BIP85WIF returns the private key of WIF format for the network param at
+//m/83696968'/2'/index'.
+func (k *ExtendedKey) BIP85WIF(param *Params, index uint32) (string, error) {
+	e, err := k.bip85Entropy(BIP85AppWIF, index)
+	if err != nil {
+		return "", err
+	}
+	if !validPrivateKey(e[:32]) {
+		return "", ErrInvalidChild
+	}
+	return NewPrivateKey(e[:32], param).WIFAddress(), nil
+}
+
+//BIP85XPRV returns the master extended private key of the network of k at
+//m/83696968'/32'/index'. Unlike NewMaster, the first half of the entropy is
+//the chain code and the second half is the private key.
+func (k *ExtendedKey) BIP85XPRV(index uint32) (*ExtendedKey, error) {
+	e, err := k.bip85Entropy(BIP85AppXPRV, index)
+	if err != nil {
+		return nil, err
+	}
+	if !validPrivateKey(e[32:]) {
+		return nil, ErrInvalidChild
+	}
+	return newExtendedKey(e[32:], e[:32], []byte{0, 0, 0, 0}, 0, 0, true,
+		k.param), nil
+}
+
+//BIP85Hex returns numBytes (16 to 64) bytes of entropy in hex at
+//m/83696968'/128169'/numBytes'/index'.
+func (k *ExtendedKey) BIP85Hex(numBytes int, index uint32) (string, error) {
+	if numBytes < 16 || numBytes > 64 {
+		return "", fmt.Errorf("%w: %d bytes", ErrInvalidBIP85Param, numBytes)
+	}
+	e, err := k.bip85Entropy(BIP85AppHex, uint32(numBytes), index)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(e[:numBytes]), nil
+}
+
+//BIP85Base64Password returns the password of length (20 to 86) characters
+//in base64 at m/83696968'/707764'/length'/index'.
+func (k *ExtendedKey) BIP85Base64Password(length int, index uint32) (string, error) {
+	if length < 20 || length > 86 {
+		return "", fmt.Errorf("%w: password length %d", ErrInvalidBIP85Param, length)
+	}
+	e, err := k.bip85Entropy(BIP85AppBase64, uint32(length), index)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(e)[:length], nil
+}
+
+//BIP85Base85Password returns the password of length (10 to 80) characters
+//in base85 at m/83696968'/707785'/length'/index'.
+func (k *ExtendedKey) BIP85Base85Password(length int, index uint32) (string, error) {
+	if length < 10 || length > 80 {
+		return "", fmt.Errorf("%w: password length %d", ErrInvalidBIP85Param, length)
+	}
+	e, err := k.bip85Entropy(BIP85AppBase85, uint32(length), index)
+	if err != nil {
+		return "", err
+	}
+	return encodeBase85(e)[:length], nil
+}
+
+//encodeBase85 encodes b, whose length is a multiple of 4, in base85.
+func encodeBase85(b []byte) string {
+	out := make([]byte, 0, len(b)/4*5)
+	for i := 0; i+4 <= len(b); i += 4 {
+		n := uint32(b[i])<<24 | uint32(b[i+1])<<16 | uint32(b[i+2])<<8 | uint32(b[i+3])
+		var chunk [5]byte
+		for j := 4; j >= 0; j-- {
+			chunk[j] = base85Chars[n%85]
+			n /= 85
+		}
+		out = append(out, chunk[:]...)
+	}
+	return string(out)
+}
+
+//BIP85Dice returns rolls rolls of a die with sides (at least 2) sides at
+//m/83696968'/89101'/sides'/rolls'/index'. Each roll is in [0, sides-1].
+//sides, rolls and index must be less than HardenedKeyStart.
+func (k *ExtendedKey) BIP85Dice(sides, rolls uint32, index uint32) ([]uint32, error) {
+	if sides < 2 || rolls < 1 || sides >= HardenedKeyStart ||
+		rolls >= HardenedKeyStart {
+		return nil, fmt.Errorf("%w: %d rolls of %d sides", ErrInvalidBIP85Param,
+			rolls, sides)
+	}
+	e, err := k.bip85Entropy(BIP85AppDice, sides, rolls, index)
+	if err != nil {
+		return nil, err
+	}
+	drng := NewBIP85DRNG(e)
+
+	//Take the most significant bits of each trial and retry if it is not
+	//less than sides.
+	bitsPerRoll := bits.Len32(sides - 1)
+	buf := make([]byte, (bitsPerRoll+7)/8)
+	//rolls comes from the caller, so let the result grow as the dice are
+	//rolled instead of allocating it up front.
+	var result []uint32
+	for uint32(len(result)) < rolls {
+		if _, err := io.ReadFull(drng, buf); err != nil {
+			return nil, err
+		}
+		var n uint64
+		for _, b := range buf {
+			n = n<<8 | uint64(b)
+		}
+		n >>= uint(len(buf)*8 - bitsPerRoll)
+		if n < uint64(sides) {
+			result = append(result, uint32(n))
+		}
+	}
+	return result, nil
+}
+
+//validPrivateKey returns true if key is in [1, n-1].
+func validPrivateKey(key []byte) bool {
+	n := new(big.Int).SetBytes(key)
+	return n.Sign() != 0 && n.Cmp(btcec.S256().N) < 0
+}
@@ -0,0 +1,235 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+)
+
+const bip85Master = "xprv9s21ZrQH143K2LBWUUQRFXhucrQqBpKdRRxNVq2zBqsx8HVqFk2uYo8kmbaLLHRdqtQpUm98uKfu3vca1LqdGhUtyoFnCNkfmXRyPXLjbKb"
+
+func bip85MasterKey(t *testing.T) *ExtendedKey {
+	master, err := NewKeyFromString(bip85Master, BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return master
+}
+
+//test vectors from BIP85.
+func TestBIP85Entropy(t *testing.T) {
+	master := bip85MasterKey(t)
+	tests := []struct {
+		path    string
+		entropy string
+	}{
+		{"m/0'/0'", "efecfbccffea313214232d29e71563d941229afb4338c21f9517c41aaa0d16f00b83d2a09ef747e7a64e8e2bd5a14869e693da66ce94ac2da570ab7ee48618f7"},
+		{"m/0'/1'", "70c6e3e8ebee8dc4c0dbba66076819bb8c09672527c4277ca8729532ad711872218f826919f6b67218adde99018a6df9095ab2b58d803b5b93ec9802085a690e"},
+	}
+	for _, test := range tests {
+		path, err := ParseDerivationPath(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := master.BIP85Entropy(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(e) != test.entropy {
+			t.Errorf("%s: got %x", test.path, e)
+		}
+	}
+
+	e, err := master.bip85Entropy(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, 80)
+	if _, err := io.ReadFull(NewBIP85DRNG(e), out); err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(out) != "b78b1ee6b345eae6836c2d53d33c64cdaf9a696487be81b03e822dc84b3f1cd883d7559e53d175f243e4c349e822a957bbff9224bc5dde9492ef54e8a439f6bc8c7355b87a925a37ee405a7502991111" {
+		t.Errorf("DRNG: got %x", out)
+	}
+
+	pub, err := master.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pub.bip85Entropy(0, 0); err != ErrNotPrivExtKey {
+		t.Errorf("public key should not derive entropy: %v", err)
+	}
+}
+
+func TestBIP85Mnemonic(t *testing.T) {
+	master := bip85MasterKey(t)
+	tests := []struct {
+		wordlist *Wordlist
+		words    int
+		entropy  string
+		mnemonic string
+	}{
+		{English, 12, "6250b68daf746d12a24d58b4787a714b",
+			"girl mad pet galaxy egg matter matrix prison refuse sense ordinary nose"},
+		{English, 18, "938033ed8b12698449d4bbca3c853c66b293ea1b1ce9d9dc",
+			"near account window bike charge season chef number sketch tomorrow excuse sniff circle vital hockey outdoor supply token"},
+		{English, 24, "ae131e2312cdc61331542efe0d1077bac5ea803adf24b313a4f0e48e9c51f37f",
+			"puppy ocean match cereal symbol another shed magic wrap hammer bulb intact gadget divorce twin tonight reason outdoor destroy simple truth cigar social volcano"},
+		{English, 15, "04200996b62ec834559877e289ca38825ce360c0", ""},
+		{Japanese, 12, "2536954d9c7b38f2b3a70e8aab996381", ""},
+		{Portuguese, 12, "d4d61e0b270e907205b394af1efbf2a0", ""},
+	}
+	for _, test := range tests {
+		m, err := master.BIP85Mnemonic(test.wordlist, test.words, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.mnemonic != "" && m != test.mnemonic {
+			t.Errorf("%s %d words: got %q", test.wordlist.Name, test.words, m)
+		}
+		e, err := MnemonicToEntropy(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(e) != test.entropy {
+			t.Errorf("%s %d words: entropy %x", test.wordlist.Name, test.words, e)
+		}
+		if w, err := DetectWordlist(m); err != nil || w != test.wordlist {
+			t.Errorf("%s %d words: wrong wordlist %v", test.wordlist.Name, test.words, err)
+		}
+	}
+	for _, words := range []int{0, 11, 13, 27} {
+		if _, err := master.BIP85Mnemonic(English, words, 0); !errors.Is(err, ErrInvalidBIP85Param) {
+			t.Errorf("%d words: unexpected error %v", words, err)
+		}
+	}
+	other := NewWordlist("other", " ", englishWords)
+	if _, err := master.BIP85Mnemonic(other, 12, 0); !errors.Is(err, ErrInvalidBIP85Param) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestBIP85Keys(t *testing.T) {
+	master := bip85MasterKey(t)
+	tests := []struct {
+		param *Params
+		index uint32
+		wif   string
+	}{
+		{BitcoinMain, 0, "Kzyv4uF39d4Jrw2W7UryTHwZr1zQVNk4dAFyqE6BuMrMh1Za7uhp"},
+		{MonacoinMain, 1, "TT5KGmCrYTeSzboucsnu1dsXR2RKqEcgcLXapmLd6DSzQnzLsdVN"},
+	}
+	for _, test := range tests {
+		wif, err := master.BIP85WIF(test.param, test.index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wif != test.wif {
+			t.Errorf("%s: got %s", test.param.Name, wif)
+		}
+	}
+
+	xprv, err := master.BIP85XPRV(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xprv.String() != "xprv9s21ZrQH143K2srSbCSg4m4kLvPMzcWydgmKEnMmoZUurYuBuYG46c6P71UGXMzmriLzCCBvKQWBUv3vPB3m1SATMhp3uEjXHJ42jFg7myX" {
+		t.Errorf("xprv: got %s", xprv)
+	}
+}
+
+func TestBIP85Passwords(t *testing.T) {
+	master := bip85MasterKey(t)
+	h, err := master.BIP85Hex(64, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h != "492db4698cf3b73a5a24998aa3e9d7fa96275d85724a91e71aa2d645442f878555d078fd1f1f67e368976f04137b1f7a0d19232136ca50c44614af72b5582a5c" {
+		t.Errorf("hex: got %s", h)
+	}
+	p, err := master.BIP85Base64Password(21, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != "dKLoepugzdVJvdL56ogNV" {
+		t.Errorf("base64: got %s", p)
+	}
+	p, err = master.BIP85Base85Password(12, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != "_s`{TW89)i4`" {
+		t.Errorf("base85: got %s", p)
+	}
+	rolls, err := master.BIP85Dice(6, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint32{1, 0, 0, 2, 0, 1, 5, 5, 2, 4}
+	for i := range want {
+		if rolls[i] != want[i] {
+			t.Errorf("dice: got %v", rolls)
+			break
+		}
+	}
+
+	if _, err := master.BIP85Hex(15, 0); !errors.Is(err, ErrInvalidBIP85Param) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := master.BIP85Base64Password(87, 0); !errors.Is(err, ErrInvalidBIP85Param) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := master.BIP85Base85Password(9, 0); !errors.Is(err, ErrInvalidBIP85Param) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := master.BIP85Dice(1, 10, 0); !errors.Is(err, ErrInvalidBIP85Param) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	//indexes which would wrap around to non-hardened ones.
+	if _, err := master.BIP85Mnemonic(English, 12, HardenedKeyStart); !errors.Is(err, ErrInvalidBIP85Param) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := master.BIP85WIF(BitcoinMain, 0xffffffff); !errors.Is(err, ErrInvalidBIP85Param) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := master.BIP85Dice(HardenedKeyStart, 10, 0); !errors.Is(err, ErrInvalidBIP85Param) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := master.BIP85Dice(6, 0xffffffff, 0); !errors.Is(err, ErrInvalidBIP85Param) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if e := encodeBase85(bytes.Repeat([]byte{0xff}, 4)); e != "|NsC0" {
+		t.Errorf("base85: got %s", e)
+	}
+}
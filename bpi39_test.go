@@ -23,6 +23,7 @@
 package address
 
 import (
+	"bytes"
 	"encoding/hex"
 	"testing"
 )
@@ -48,17 +49,17 @@ func TestBip39(t *testing.T) {
 			t.Error("mnemonic not equal")
 		}
 
-		// expectedSeed, err := hex.DecodeString(vector.seed)
+		ent, err := MnemonicToEntropy(mnemonic)
+		if err != nil {
+			t.Error(i, err)
+		}
+		if !bytes.Equal(ent, entropy) {
+			t.Error(i, "entropy not equal")
+		}
+
 		_, err = NewSeedWithErrorChecking(mnemonic, "TREZOR")
-		// Test Vectors 0, 4, and 8 do not work as intended.
-		if (i != 0) && (i != 4) && (i != 8) {
-			if err != nil {
-				t.Error(err)
-			}
-		} else {
-			if err == nil {
-				t.Error("err should not be nil")
-			}
+		if err != nil {
+			t.Error(i, err)
 		}
 		seed := NewSeed(mnemonic, "TREZOR")
 		if vector.seed != hex.EncodeToString(seed) {
@@ -83,13 +84,34 @@ func TestIsMnemonicValid(t *testing.T) {
 
 func TestInvalidMnemonicFails(t *testing.T) {
 	for _, vector := range badMnemonicSentences() {
-		_, err := MnemonicToByteArray(vector.mnemonic)
+		_, err := MnemonicToEntropy(vector.mnemonic)
 		if err == nil {
 			t.Error("err should not be nil")
 		}
 	}
 }
 
+func TestMnemonicToEntropyErrors(t *testing.T) {
+	_, err := MnemonicToEntropy("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon")
+	if err != ErrInvalidWordCount {
+		t.Error("invalid word count should be reported", err)
+	}
+
+	_, err = MnemonicToEntropy("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon")
+	if err != ErrMnemonicChecksum {
+		t.Error("checksum mismatch should be reported", err)
+	}
+
+	_, err = MnemonicToEntropy("legal winner thank year wave sausage worth useful legal winner thanks yellow")
+	uerr, ok := err.(*UnknownWordError)
+	if !ok {
+		t.Fatal("unknown word should be reported", err)
+	}
+	if uerr.Position != 10 || uerr.Word != "thanks" {
+		t.Error("invalid unknown word", uerr.Position, uerr.Word)
+	}
+}
+
 func TestValidateEntropyWithChecksumBitSize(t *testing.T) {
 	// Good tests.
 	for i := 1; i <= (12*32 + 12); i++ {
@@ -0,0 +1,209 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package btcec
+
+// Adaptor signatures are [BIP340] Schnorr signatures encrypted under an
+// adaptor point T = t*G.  Anyone can verify that a pre-signature becomes a
+// valid signature with t, and t is revealed to the signer once the signature
+// is published.  Because the same T can be used on any chain on secp256k1,
+// two pre-signatures under T make an atomic swap without hash locks: the
+// party who knows t must publish a signature revealing t to complete its
+// side, which lets the counterparty complete the other side.
+//
+// The pre-signature is the 33 bytes compressed point R = k*G + T followed by
+// the 32 bytes s' = k + e*d, where the final signature is (R, s'+t).  If R
+// has the odd y coordinate, k is negated and the final signature is
+// (-R, s'-t), so that its nonce has the even y coordinate.
+
+import (
+	"errors"
+	"math/big"
+)
+
+// AdaptorSigLen is the length of a pre-signature.
+const AdaptorSigLen = 65
+
+var (
+	// ErrInvalidAdaptorSig describes an error in which the pre-signature is
+	// malformed or doesn't match the signature.
+	ErrInvalidAdaptorSig = errors.New("invalid adaptor pre-signature")
+
+	// ErrInvalidAdaptorSecret describes an error in which the adaptor
+	// secret is out of range or is not the discrete logarithm of the
+	// adaptor point.
+	ErrInvalidAdaptorSecret = errors.New("invalid adaptor secret")
+)
+
+// SignAdaptor creates the pre-signature of the 32 bytes msg with the
+// private key, encrypted under the adaptor point.  auxRand is 32 bytes of
+// fresh random data as in SignSchnorr.
+func SignAdaptor(priv *PrivateKey, msg, auxRand []byte, adaptor *PublicKey) ([]byte, error) {
+	if len(msg) != 32 || len(auxRand) != 32 {
+		return nil, ErrInvalidSchnorrInput
+	}
+	if !validPoint(adaptor) {
+		return nil, ErrInvalidAdaptorSig
+	}
+	curve := S256()
+	d := new(big.Int).Set(priv.D)
+	if d.Sign() == 0 || d.Cmp(curve.N) >= 0 {
+		return nil, ErrInvalidSchnorrKey
+	}
+	p := mulBase(d)
+	if isOdd(p.y) {
+		d.Sub(curve.N, d)
+	}
+	pBytes := scalarBytes(p.x)
+
+	// The nonce also commits to the adaptor point, so that pre-signatures
+	// of the same message under different adaptors never share k.
+	t := TaggedHash("BIP0340/aux", auxRand)
+	dBytes := scalarBytes(d)
+	for i := range t {
+		t[i] ^= dBytes[i]
+	}
+	k := hashToScalar("SchnorrAdaptor/nonce", t, pBytes,
+		adaptor.SerializeCompressed(), msg)
+	if k.Sign() == 0 {
+		return nil, errors.New("nonce is zero")
+	}
+	r := addPoints(mulBase(k), pubKeyPoint(adaptor))
+	if r.isInfinity() {
+		return nil, errors.New("nonce is infinity")
+	}
+	if isOdd(r.y) {
+		k.Sub(curve.N, k)
+	}
+
+	// s' = k + e*d mod n
+	e := hashToScalar("BIP0340/challenge", scalarBytes(r.x), pBytes, msg)
+	s := e.Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, curve.N)
+
+	preSig := append(serializeCompressedPoint(r), scalarBytes(s)...)
+	if !VerifyAdaptor(pBytes, msg, preSig, adaptor) {
+		return nil, errors.New("created pre-signature does not verify")
+	}
+	return preSig, nil
+}
+
+// parseAdaptorSig parses the pre-signature into R and s'.
+func parseAdaptorSig(preSig []byte) (point, *big.Int, error) {
+	if len(preSig) != AdaptorSigLen {
+		return point{}, nil, ErrInvalidAdaptorSig
+	}
+	r, err := parseCompressedPoint(preSig[:33], false)
+	if err != nil {
+		return point{}, nil, ErrInvalidAdaptorSig
+	}
+	s := new(big.Int).SetBytes(preSig[33:])
+	if s.Cmp(S256().N) >= 0 {
+		return point{}, nil, ErrInvalidAdaptorSig
+	}
+	return r, s, nil
+}
+
+// VerifyAdaptor returns true if preSig is the valid pre-signature of the 32
+// bytes msg for the x-only public key pubKey under the adaptor point, i.e.
+// it becomes a valid [BIP340] signature with the adaptor secret.
+func VerifyAdaptor(pubKey, msg, preSig []byte, adaptor *PublicKey) bool {
+	if len(msg) != 32 || !validPoint(adaptor) {
+		return false
+	}
+	p, err := ParseXOnlyPubKey(pubKey)
+	if err != nil {
+		return false
+	}
+	r, s, err := parseAdaptorSig(preSig)
+	if err != nil {
+		return false
+	}
+	e := hashToScalar("BIP0340/challenge", scalarBytes(r.x), pubKey, msg)
+
+	// s'*G == ±(R - T) + e*P
+	rk := addPoints(r, negPoint(pubKeyPoint(adaptor)))
+	if isOdd(r.y) {
+		rk = negPoint(rk)
+	}
+	want := addPoints(rk, mulPoint(pubKeyPoint(p), e))
+	got := mulBase(s)
+	return !want.isInfinity() && got.x.Cmp(want.x) == 0 &&
+		got.y.Cmp(want.y) == 0
+}
+
+// AdaptSignature completes the pre-signature with the 32 bytes adaptor
+// secret and returns the 64 bytes [BIP340] signature.  It doesn't check the
+// secret, so verify the result with VerifySchnorr.
+func AdaptSignature(preSig, secret []byte) ([]byte, error) {
+	curve := S256()
+	r, s, err := parseAdaptorSig(preSig)
+	if err != nil {
+		return nil, err
+	}
+	t := new(big.Int).SetBytes(secret)
+	if len(secret) != 32 || t.Sign() == 0 || t.Cmp(curve.N) >= 0 {
+		return nil, ErrInvalidAdaptorSecret
+	}
+	if isOdd(r.y) {
+		t.Neg(t)
+	}
+	s.Add(s, t)
+	s.Mod(s, curve.N)
+	return append(scalarBytes(r.x), scalarBytes(s)...), nil
+}
+
+// ExtractAdaptorSecret returns the 32 bytes adaptor secret from the
+// pre-signature and the signature completed from it.  It returns
+// ErrInvalidAdaptorSecret if the secret doesn't match the adaptor point,
+// i.e. the signature is not completed from the pre-signature.
+func ExtractAdaptorSecret(preSig, sig []byte, adaptor *PublicKey) ([]byte, error) {
+	curve := S256()
+	r, s, err := parseAdaptorSig(preSig)
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != SchnorrSigLen || new(big.Int).SetBytes(sig[:32]).Cmp(r.x) != 0 {
+		return nil, ErrInvalidAdaptorSig
+	}
+	t := new(big.Int).SetBytes(sig[32:])
+	if t.Cmp(curve.N) >= 0 {
+		return nil, ErrInvalidAdaptorSig
+	}
+	t.Sub(t, s)
+	if isOdd(r.y) {
+		t.Neg(t)
+	}
+	t.Mod(t, curve.N)
+	if t.Sign() == 0 || !validPoint(adaptor) ||
+		!mulBase(t).pubKey().IsEqual(adaptor) {
+		return nil, ErrInvalidAdaptorSecret
+	}
+	return scalarBytes(t), nil
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package btcec
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+// TestAdaptorSignature tests signing, verifying, completing and extracting
+// with nonces of both parities.
+func TestAdaptorSignature(t *testing.T) {
+	priv, pub := PrivKeyFromBytes(S256(),
+		decodeHex("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF"))
+	pubKey := pub.SerializeXOnly()
+	msg := decodeHex("243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89")
+	aux := make([]byte, 32)
+
+	parities := make(map[bool]bool)
+	for i := int64(1); i <= 8; i++ {
+		secret := scalarBytes(big.NewInt(i))
+		_, adaptor := PrivKeyFromBytes(S256(), secret)
+		preSig, err := SignAdaptor(priv, msg, aux, adaptor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parities[preSig[0] == 0x03] = true
+		if !VerifyAdaptor(pubKey, msg, preSig, adaptor) {
+			t.Errorf("secret %d: pre-signature should verify", i)
+		}
+		if VerifySchnorr(pubKey, msg, append(preSig[1:33], preSig[33:]...)) {
+			t.Errorf("secret %d: pre-signature should not be a signature", i)
+		}
+
+		sig, err := AdaptSignature(preSig, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifySchnorr(pubKey, msg, sig) {
+			t.Errorf("secret %d: completed signature should verify", i)
+		}
+		got, err := ExtractAdaptorSecret(preSig, sig, adaptor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Errorf("secret %d: extracted %x", i, got)
+		}
+
+		wrong, err := AdaptSignature(preSig, scalarBytes(big.NewInt(i+1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if VerifySchnorr(pubKey, msg, wrong) {
+			t.Errorf("secret %d: completing with a wrong secret should fail", i)
+		}
+		if _, err := ExtractAdaptorSecret(preSig, wrong, adaptor); err != ErrInvalidAdaptorSecret {
+			t.Errorf("secret %d: unexpected error %v", i, err)
+		}
+	}
+	if !parities[true] || !parities[false] {
+		t.Error("nonces of both parities should be tested")
+	}
+}
+
+// TestAdaptorInvalid tests that pre-signatures don't verify for other
+// inputs.
+func TestAdaptorInvalid(t *testing.T) {
+	priv, _ := NewPrivateKey(S256())
+	other, _ := NewPrivateKey(S256())
+	adaptorPriv, _ := NewPrivateKey(S256())
+	adaptor := adaptorPriv.PubKey()
+	msg := TaggedHash("test", []byte("adaptor"))
+	aux := TaggedHash("test", []byte("aux"))
+	preSig, err := SignAdaptor(priv, msg, aux, adaptor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey := priv.PubKey().SerializeXOnly()
+
+	if VerifyAdaptor(other.PubKey().SerializeXOnly(), msg, preSig, adaptor) {
+		t.Error("should not verify for another key")
+	}
+	if VerifyAdaptor(pubKey, aux, preSig, adaptor) {
+		t.Error("should not verify for another message")
+	}
+	if VerifyAdaptor(pubKey, msg, preSig, other.PubKey()) {
+		t.Error("should not verify for another adaptor")
+	}
+	tampered := append([]byte{}, preSig...)
+	tampered[64] ^= 1
+	if VerifyAdaptor(pubKey, msg, tampered, adaptor) {
+		t.Error("tampered pre-signature should not verify")
+	}
+	if VerifyAdaptor(pubKey, msg, preSig[:64], adaptor) {
+		t.Error("short pre-signature should not verify")
+	}
+	if _, err := AdaptSignature(preSig, make([]byte, 32)); err != ErrInvalidAdaptorSecret {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// A signature not completed from the pre-signature.
+	sig, err := SignSchnorr(priv, msg, aux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExtractAdaptorSecret(preSig, sig, adaptor); err != ErrInvalidAdaptorSig {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// TestAdaptorSwap tests an atomic swap, where Bob learns the secret of Alice
+// from her signature on one chain and completes his on the other chain.
+func TestAdaptorSwap(t *testing.T) {
+	alice, _ := NewPrivateKey(S256())
+	bob, _ := NewPrivateKey(S256())
+	secretKey, _ := NewPrivateKey(S256())
+	secret := scalarBytes(secretKey.D)
+	adaptor := secretKey.PubKey()
+	aux := make([]byte, 32)
+
+	// Bob pays Alice on chain A, Alice pays Bob on chain B.
+	msgA := TaggedHash("test", []byte("chain A"))
+	msgB := TaggedHash("test", []byte("chain B"))
+	preBob, err := SignAdaptor(bob, msgA, aux, adaptor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	preAlice, err := SignAdaptor(alice, msgB, aux, adaptor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Alice, who knows the secret, completes Bob's pre-signature.
+	if !VerifyAdaptor(bob.PubKey().SerializeXOnly(), msgA, preBob, adaptor) {
+		t.Fatal("pre-signature of Bob should verify")
+	}
+	sigBob, err := AdaptSignature(preBob, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifySchnorr(bob.PubKey().SerializeXOnly(), msgA, sigBob) {
+		t.Fatal("signature of Bob should verify")
+	}
+
+	// Bob extracts the secret from the published signature.
+	got, err := ExtractAdaptorSecret(preBob, sigBob, adaptor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigAlice, err := AdaptSignature(preAlice, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifySchnorr(alice.PubKey().SerializeXOnly(), msgB, sigAlice) {
+		t.Error("signature of Alice should verify")
+	}
+}
@@ -0,0 +1,654 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package btcec
+
+// References:
+//   [FROST]: FROST: Flexible Round-Optimized Schnorr Threshold Signatures
+//   https://eprint.iacr.org/2020/852.pdf
+//
+//   [RFC9591]: The Flexible Round-Optimized Schnorr Threshold (FROST)
+//   Protocol for Two-Round Schnorr Signatures
+//   https://www.rfc-editor.org/rfc/rfc9591
+//
+// Signatures are [BIP340] Schnorr signatures of the x-only group public key,
+// so the challenge is the one of [BIP340] and the signers negate their
+// shares and nonces when the group key or the group nonce has the odd y
+// coordinate.
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sort"
+)
+
+var (
+	// ErrFrostThreshold describes an error in which the threshold is not in
+	// [1, n] or the identifier of a participant is not in [1, n].
+	ErrFrostThreshold = errors.New("threshold and identifiers must be in " +
+		"[1, n]")
+
+	// ErrFrostNotEnoughSigners describes an error in which fewer
+	// participants than the threshold take part in signing or fewer
+	// packages than n are given in the key generation.
+	ErrFrostNotEnoughSigners = errors.New("not enough participants to " +
+		"reach the threshold")
+
+	// ErrFrostDuplicateID describes an error in which two participants have
+	// the same identifier.
+	ErrFrostDuplicateID = errors.New("duplicate participant identifier")
+
+	// ErrFrostNonceUsed describes an error in which the nonce was already
+	// used or was not generated for the key share.
+	ErrFrostNonceUsed = errors.New("nonce is used or invalid")
+
+	// ErrFrostNotSigner describes an error in which the participant is not
+	// one of the signers of the session.
+	ErrFrostNotSigner = errors.New("participant is not a signer of the " +
+		"session")
+)
+
+// randScalar returns a random scalar in [1, n-1].
+func randScalar() (*big.Int, error) {
+	for {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		k := new(big.Int).SetBytes(b)
+		if k.Sign() != 0 && k.Cmp(S256().N) < 0 {
+			return k, nil
+		}
+	}
+}
+
+// idBytes returns the identifier as 4 bytes big endian.
+func idBytes(id uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], id)
+	return b[:]
+}
+
+// validPoint returns true if p is a point on the curve.
+func validPoint(p *PublicKey) bool {
+	return p != nil && p.X != nil && p.Y != nil && S256().IsOnCurve(p.X, p.Y)
+}
+
+// VSSCommitment is the Feldman VSS commitment to a polynomial of degree t-1,
+// i.e. the coefficients multiplied by the generator, where the first point
+// is the commitment to the secret.
+type VSSCommitment []*PublicKey
+
+// Threshold returns the number of shares needed to recover the secret.
+func (c VSSCommitment) Threshold() int {
+	return len(c)
+}
+
+// PubKey returns the commitment to the secret, which is the group public key
+// for the commitment of the group polynomial.
+func (c VSSCommitment) PubKey() *PublicKey {
+	return c[0]
+}
+
+// VerificationShare returns the public key of the share of the participant
+// id, i.e. the polynomial evaluated at id in the exponent.
+func (c VSSCommitment) VerificationShare(id uint32) *PublicKey {
+	x := big.NewInt(int64(id))
+	xi := big.NewInt(1)
+	r := infinity()
+	for _, p := range c {
+		r = addPoints(r, mulPoint(pubKeyPoint(p), xi))
+		xi.Mul(xi, x)
+		xi.Mod(xi, S256().N)
+	}
+	return r.pubKey()
+}
+
+// VerifyShare returns true if secret is the share of the participant id of
+// the committed polynomial.
+func (c VSSCommitment) VerifyShare(id uint32, secret *big.Int) bool {
+	if id == 0 || secret == nil || secret.Sign() <= 0 ||
+		secret.Cmp(S256().N) >= 0 {
+		return false
+	}
+	return mulBase(secret).pubKey().IsEqual(c.VerificationShare(id))
+}
+
+func (c VSSCommitment) valid() bool {
+	if len(c) == 0 {
+		return false
+	}
+	for _, p := range c {
+		if !validPoint(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// add returns the sum of two commitments of the same threshold.
+func (c VSSCommitment) add(o VSSCommitment) VSSCommitment {
+	r := make(VSSCommitment, len(c))
+	for i := range c {
+		r[i] = addPoints(pubKeyPoint(c[i]), pubKeyPoint(o[i])).pubKey()
+	}
+	return r
+}
+
+// polynomial is a polynomial over the scalar field.
+type polynomial []*big.Int
+
+// newPolynomial returns a random polynomial of degree t-1 whose constant
+// term is secret.
+func newPolynomial(secret *big.Int, t int) (polynomial, error) {
+	p := make(polynomial, t)
+	p[0] = secret
+	for i := 1; i < t; i++ {
+		k, err := randScalar()
+		if err != nil {
+			return nil, err
+		}
+		p[i] = k
+	}
+	return p, nil
+}
+
+func (p polynomial) evaluate(id uint32) *big.Int {
+	x := big.NewInt(int64(id))
+	r := new(big.Int)
+	for i := len(p) - 1; i >= 0; i-- {
+		r.Mul(r, x)
+		r.Add(r, p[i])
+		r.Mod(r, S256().N)
+	}
+	return r
+}
+
+func (p polynomial) commitment() VSSCommitment {
+	c := make(VSSCommitment, len(p))
+	for i, a := range p {
+		c[i] = mulBase(a).pubKey()
+	}
+	return c
+}
+
+// FrostKeyShare is the key share of a participant of [FROST].
+type FrostKeyShare struct {
+	// ID is the identifier of the participant in [1, n].
+	ID uint32
+	// Secret is the secret signing share.
+	Secret *big.Int
+	// Commitment is the commitment of the group polynomial, which is
+	// public and gives the group public key and the verification shares.
+	Commitment VSSCommitment
+}
+
+// PubKey returns the group public key.
+func (s *FrostKeyShare) PubKey() *PublicKey {
+	return s.Commitment.PubKey()
+}
+
+// Verify returns true if the secret share matches the commitment.
+func (s *FrostKeyShare) Verify() bool {
+	return s.Commitment.VerifyShare(s.ID, s.Secret)
+}
+
+// FrostDealer splits secret into n shares with the trusted dealer key
+// generation, where any threshold of the shares can sign for the group
+// public key secret*G.  If secret is nil, a random secret is used.  The
+// dealer must send each share to its participant over a secure channel and
+// forget the secret.
+func FrostDealer(secret []byte, threshold, n int) ([]*FrostKeyShare, error) {
+	if threshold < 1 || threshold > n {
+		return nil, ErrFrostThreshold
+	}
+	var s *big.Int
+	if secret == nil {
+		var err error
+		if s, err = randScalar(); err != nil {
+			return nil, err
+		}
+	} else {
+		s = new(big.Int).SetBytes(secret)
+		if len(secret) != 32 || s.Sign() == 0 || s.Cmp(S256().N) >= 0 {
+			return nil, ErrInvalidSchnorrKey
+		}
+	}
+	poly, err := newPolynomial(s, threshold)
+	if err != nil {
+		return nil, err
+	}
+	c := poly.commitment()
+	shares := make([]*FrostKeyShare, n)
+	for i := range shares {
+		id := uint32(i + 1)
+		shares[i] = &FrostKeyShare{
+			ID:         id,
+			Secret:     poly.evaluate(id),
+			Commitment: c,
+		}
+	}
+	return shares, nil
+}
+
+// FrostDKGPackage is the public message of a participant in the first round
+// of the distributed key generation, which is broadcast to all
+// participants.
+type FrostDKGPackage struct {
+	// ID is the identifier of the sender.
+	ID uint32
+	// Commitment is the commitment to the polynomial of the sender.
+	Commitment VSSCommitment
+	// Proof is the 64 bytes proof of knowledge of the secret of the
+	// sender, which prevents rogue key attacks.
+	Proof []byte
+}
+
+// FrostDKG is the state of a participant in the distributed key generation
+// of [FROST], which is Pedersen's DKG built on Feldman VSS.  No participant
+// ever knows the group secret.
+type FrostDKG struct {
+	id        uint32
+	threshold int
+	n         int
+	poly      polynomial
+}
+
+// pokChallenge returns the challenge of the proof of knowledge of the secret
+// committed in c.
+func pokChallenge(id uint32, c VSSCommitment, r point) *big.Int {
+	return hashToScalar("FROST/pok", idBytes(id), c[0].SerializeCompressed(),
+		serializeCompressedPoint(r))
+}
+
+// NewFrostDKG starts the distributed key generation as the participant id
+// in [1, n] and returns the package to broadcast in the first round.
+func NewFrostDKG(id uint32, threshold, n int) (*FrostDKG, *FrostDKGPackage, error) {
+	if threshold < 1 || threshold > n || id < 1 || int64(id) > int64(n) {
+		return nil, nil, ErrFrostThreshold
+	}
+	s, err := randScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	poly, err := newPolynomial(s, threshold)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := poly.commitment()
+
+	// Schnorr proof of knowledge of poly[0] with R of the even y
+	// coordinate: mu = k + a0*c.
+	k, err := randScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	r := mulBase(k)
+	if isOdd(r.y) {
+		k.Sub(S256().N, k)
+		r = negPoint(r)
+	}
+	mu := new(big.Int).Mul(poly[0], pokChallenge(id, c, r))
+	mu.Add(mu, k)
+	mu.Mod(mu, S256().N)
+	proof := append(scalarBytes(r.x), scalarBytes(mu)...)
+	d := &FrostDKG{id: id, threshold: threshold, n: n, poly: poly}
+	return d, &FrostDKGPackage{ID: id, Commitment: c, Proof: proof}, nil
+}
+
+// verifyPackage verifies the commitment and the proof of knowledge.
+func (d *FrostDKG) verifyPackage(p *FrostDKGPackage) bool {
+	if len(p.Commitment) != d.threshold || !p.Commitment.valid() ||
+		len(p.Proof) != 64 {
+		return false
+	}
+	rp, err := ParseXOnlyPubKey(p.Proof[:32])
+	if err != nil {
+		return false
+	}
+	r := pubKeyPoint(rp)
+	mu := new(big.Int).SetBytes(p.Proof[32:])
+	if mu.Cmp(S256().N) >= 0 {
+		return false
+	}
+
+	// mu*G == R + c*C0
+	want := addPoints(r, mulPoint(pubKeyPoint(p.Commitment[0]),
+		pokChallenge(p.ID, p.Commitment, r)))
+	got := mulBase(mu)
+	return got.x.Cmp(want.x) == 0 && got.y.Cmp(want.y) == 0
+}
+
+// checkPackages checks that there is one valid package from every other
+// participant.  It returns InvalidContributionError naming the sender of an
+// invalid package.
+func (d *FrostDKG) checkPackages(pkgs []*FrostDKGPackage) (map[uint32]*FrostDKGPackage, error) {
+	m := make(map[uint32]*FrostDKGPackage)
+	for _, p := range pkgs {
+		if p.ID == d.id {
+			continue
+		}
+		if p.ID < 1 || int64(p.ID) > int64(d.n) {
+			return nil, ErrFrostThreshold
+		}
+		if _, ok := m[p.ID]; ok {
+			return nil, ErrFrostDuplicateID
+		}
+		if !d.verifyPackage(p) {
+			return nil, &InvalidContributionError{Signer: int(p.ID),
+				Contrib: "commitment"}
+		}
+		m[p.ID] = p
+	}
+	if len(m) != d.n-1 {
+		return nil, ErrFrostNotEnoughSigners
+	}
+	return m, nil
+}
+
+// Shares verifies the packages of the other participants and returns the
+// secret shares to send to each of them over a secure channel in the second
+// round, keyed by the identifier of the receiver.  It returns
+// InvalidContributionError naming the sender of an invalid package.
+func (d *FrostDKG) Shares(pkgs []*FrostDKGPackage) (map[uint32]*big.Int, error) {
+	if _, err := d.checkPackages(pkgs); err != nil {
+		return nil, err
+	}
+	shares := make(map[uint32]*big.Int)
+	for i := 1; i <= d.n; i++ {
+		if uint32(i) != d.id {
+			shares[uint32(i)] = d.poly.evaluate(uint32(i))
+		}
+	}
+	return shares, nil
+}
+
+// Finalize verifies the secret shares received from the other participants,
+// keyed by the identifier of the sender, against their packages and returns
+// the key share of the participant.  It returns InvalidContributionError
+// naming the sender of an invalid share, who must be excluded.
+func (d *FrostDKG) Finalize(pkgs []*FrostDKGPackage, shares map[uint32]*big.Int) (*FrostKeyShare, error) {
+	m, err := d.checkPackages(pkgs)
+	if err != nil {
+		return nil, err
+	}
+	secret := d.poly.evaluate(d.id)
+	c := d.poly.commitment()
+	ids := make([]uint32, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		s, ok := shares[id]
+		if !ok || !m[id].Commitment.VerifyShare(d.id, s) {
+			return nil, &InvalidContributionError{Signer: int(id),
+				Contrib: "share"}
+		}
+		secret.Add(secret, s)
+		c = c.add(m[id].Commitment)
+	}
+	secret.Mod(secret, S256().N)
+	share := &FrostKeyShare{ID: d.id, Secret: secret, Commitment: c}
+	if secret.Sign() == 0 || !share.Verify() {
+		return nil, errors.New("invalid key share")
+	}
+	return share, nil
+}
+
+// FrostNonce is the secret nonce of a signer for one signing session.
+type FrostNonce struct {
+	id   uint32
+	d, e *big.Int
+}
+
+// FrostNonceCommitment is the public commitment to a FrostNonce, which is
+// sent to the coordinator in the first round of signing.
+type FrostNonceCommitment struct {
+	ID uint32
+	D  *PublicKey
+	E  *PublicKey
+}
+
+// frostNonce derives a nonce from fresh randomness and the secret share as
+// in [RFC9591], so that a weak random source alone doesn't leak the share.
+func frostNonce(secret *big.Int) (*big.Int, error) {
+	rnd := make([]byte, 32)
+	if _, err := rand.Read(rnd); err != nil {
+		return nil, err
+	}
+	k := hashToScalar("FROST/nonce", rnd, scalarBytes(secret))
+	if k.Sign() == 0 {
+		return nil, errors.New("nonce is zero")
+	}
+	return k, nil
+}
+
+// FrostNonceGen generates the secret nonce and its commitment for the key
+// share in the first round of signing.  A nonce must be used only once.
+func FrostNonceGen(share *FrostKeyShare) (*FrostNonce, *FrostNonceCommitment, error) {
+	d, err := frostNonce(share.Secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	e, err := frostNonce(share.Secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &FrostNonce{id: share.ID, d: d, e: e},
+		&FrostNonceCommitment{
+			ID: share.ID,
+			D:  mulBase(d).pubKey(),
+			E:  mulBase(e).pubKey(),
+		}, nil
+}
+
+// FrostSession is the signing session of a message by a set of signers,
+// which is shared by the coordinator and the signers in the second round.
+type FrostSession struct {
+	group VSSCommitment
+	msg   []byte
+	ids   []uint32
+	comms map[uint32]*FrostNonceCommitment
+	rho   map[uint32]*big.Int
+	r     point
+	c     *big.Int
+}
+
+// NewFrostSession returns the session to sign the 32 bytes msg with the
+// group public key of the commitment by the signers of the nonce
+// commitments.  It returns InvalidContributionError naming the signer of an
+// invalid nonce commitment.
+func NewFrostSession(group VSSCommitment, comms []*FrostNonceCommitment, msg []byte) (*FrostSession, error) {
+	if len(msg) != 32 {
+		return nil, ErrInvalidSchnorrInput
+	}
+	if len(comms) < group.Threshold() {
+		return nil, ErrFrostNotEnoughSigners
+	}
+	s := &FrostSession{
+		group: group,
+		msg:   msg,
+		comms: make(map[uint32]*FrostNonceCommitment),
+		rho:   make(map[uint32]*big.Int),
+		r:     infinity(),
+	}
+	for _, c := range comms {
+		if c.ID == 0 {
+			return nil, ErrFrostThreshold
+		}
+		if _, ok := s.comms[c.ID]; ok {
+			return nil, ErrFrostDuplicateID
+		}
+		if !validPoint(c.D) || !validPoint(c.E) {
+			return nil, &InvalidContributionError{Signer: int(c.ID),
+				Contrib: "pubnonce"}
+		}
+		s.comms[c.ID] = c
+		s.ids = append(s.ids, c.ID)
+	}
+	sort.Slice(s.ids, func(i, j int) bool { return s.ids[i] < s.ids[j] })
+
+	// The binding factors commit to all nonce commitments so that a
+	// signer's nonce can't be combined with other commitments.
+	yx := group.PubKey().SerializeXOnly()
+	list := make([]byte, 0, len(s.ids)*70)
+	for _, id := range s.ids {
+		list = append(list, idBytes(id)...)
+		list = append(list, s.comms[id].D.SerializeCompressed()...)
+		list = append(list, s.comms[id].E.SerializeCompressed()...)
+	}
+	for _, id := range s.ids {
+		s.rho[id] = hashToScalar("FROST/binding", yx, msg, list, idBytes(id))
+		s.r = addPoints(s.r, s.nonceShare(id))
+	}
+	if s.r.isInfinity() {
+		return nil, errors.New("group nonce is infinity")
+	}
+	s.c = hashToScalar("BIP0340/challenge", scalarBytes(s.r.x), yx, msg)
+	return s, nil
+}
+
+// nonceShare returns D + rho*E of the signer.
+func (s *FrostSession) nonceShare(id uint32) point {
+	c := s.comms[id]
+	return addPoints(pubKeyPoint(c.D), mulPoint(pubKeyPoint(c.E), s.rho[id]))
+}
+
+// lagrange returns the Lagrange coefficient of the signer at zero.
+func (s *FrostSession) lagrange(id uint32) *big.Int {
+	n := S256().N
+	num := big.NewInt(1)
+	den := big.NewInt(1)
+	for _, j := range s.ids {
+		if j == id {
+			continue
+		}
+		num.Mul(num, big.NewInt(int64(j)))
+		num.Mod(num, n)
+		den.Mul(den, new(big.Int).Sub(big.NewInt(int64(j)), big.NewInt(int64(id))))
+		den.Mod(den, n)
+	}
+	num.Mul(num, den.ModInverse(den, n))
+	return num.Mod(num, n)
+}
+
+// Sign returns the 32 bytes signature share of the signer in the second
+// round.  The nonce is erased so that it is never reused, which would leak
+// the share.
+func (s *FrostSession) Sign(nonce *FrostNonce, share *FrostKeyShare) ([]byte, error) {
+	n := S256().N
+	if nonce.d == nil || nonce.id != share.ID {
+		return nil, ErrFrostNonceUsed
+	}
+	c, ok := s.comms[share.ID]
+	if !ok {
+		return nil, ErrFrostNotSigner
+	}
+	if !mulBase(nonce.d).pubKey().IsEqual(c.D) ||
+		!mulBase(nonce.e).pubKey().IsEqual(c.E) {
+		return nil, ErrFrostNonceUsed
+	}
+	d, e := nonce.d, nonce.e
+	nonce.d, nonce.e = nil, nil
+
+	// z = d + rho*e + lambda*secret*c with the signs of [BIP340].
+	z := new(big.Int).Mul(e, s.rho[share.ID])
+	z.Add(z, d)
+	if isOdd(s.r.y) {
+		z.Sub(n, z)
+	}
+	k := new(big.Int).Mul(s.lagrange(share.ID), share.Secret)
+	k.Mul(k, s.c)
+	if isOdd(s.group.PubKey().Y) {
+		k.Neg(k)
+	}
+	z.Add(z, k)
+	z.Mod(z, n)
+	return scalarBytes(z), nil
+}
+
+// VerifySignatureShare returns true if sigShare is the valid signature share
+// of the signer.
+func (s *FrostSession) VerifySignatureShare(id uint32, sigShare []byte) bool {
+	if _, ok := s.comms[id]; !ok || len(sigShare) != 32 {
+		return false
+	}
+	z := new(big.Int).SetBytes(sigShare)
+	if z.Cmp(S256().N) >= 0 {
+		return false
+	}
+	r := s.nonceShare(id)
+	if isOdd(s.r.y) {
+		r = negPoint(r)
+	}
+	y := pubKeyPoint(s.group.VerificationShare(id))
+	if isOdd(s.group.PubKey().Y) {
+		y = negPoint(y)
+	}
+
+	// z*G == R_i + c*lambda*Y_i
+	k := new(big.Int).Mul(s.c, s.lagrange(id))
+	want := addPoints(r, mulPoint(y, k))
+	got := mulBase(z)
+	return got.x.Cmp(want.x) == 0 && got.y.Cmp(want.y) == 0
+}
+
+// Aggregate combines the signature shares of all signers, keyed by the
+// identifier, into the [BIP340] Schnorr signature for the x-only group
+// public key.  If the signature is invalid, it returns
+// InvalidContributionError naming a signer whose share is invalid.
+func (s *FrostSession) Aggregate(sigShares map[uint32][]byte) ([]byte, error) {
+	z := new(big.Int)
+	for _, id := range s.ids {
+		share, ok := sigShares[id]
+		if !ok {
+			return nil, ErrFrostNotEnoughSigners
+		}
+		zi := new(big.Int).SetBytes(share)
+		if len(share) != 32 || zi.Cmp(S256().N) >= 0 {
+			return nil, &InvalidContributionError{Signer: int(id),
+				Contrib: "psig"}
+		}
+		z.Add(z, zi)
+	}
+	z.Mod(z, S256().N)
+	sig := append(scalarBytes(s.r.x), scalarBytes(z)...)
+	if VerifySchnorr(s.group.PubKey().SerializeXOnly(), s.msg, sig) {
+		return sig, nil
+	}
+	for _, id := range s.ids {
+		if !s.VerifySignatureShare(id, sigShares[id]) {
+			return nil, &InvalidContributionError{Signer: int(id),
+				Contrib: "psig"}
+		}
+	}
+	return nil, errors.New("signature is invalid")
+}
@@ -0,0 +1,291 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package btcec
+
+import (
+	"math/big"
+	"testing"
+)
+
+// frostSign signs msg by the shares and returns the signature.
+func frostSign(t *testing.T, shares []*FrostKeyShare, msg []byte) []byte {
+	nonces := make([]*FrostNonce, len(shares))
+	comms := make([]*FrostNonceCommitment, len(shares))
+	for i, share := range shares {
+		var err error
+		nonces[i], comms[i], err = FrostNonceGen(share)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := NewFrostSession(shares[0].Commitment, comms, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigShares := make(map[uint32][]byte)
+	for i, share := range shares {
+		z, err := s.Sign(nonces[i], share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !s.VerifySignatureShare(share.ID, z) {
+			t.Errorf("signature share of %d should verify", share.ID)
+		}
+		sigShares[share.ID] = z
+	}
+	sig, err := s.Aggregate(sigShares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+// subsets returns all subsets of shares with k elements.
+func subsets(shares []*FrostKeyShare, k int) [][]*FrostKeyShare {
+	if k == 0 {
+		return [][]*FrostKeyShare{nil}
+	}
+	if len(shares) < k {
+		return nil
+	}
+	var r [][]*FrostKeyShare
+	for _, s := range subsets(shares[1:], k-1) {
+		r = append(r, append([]*FrostKeyShare{shares[0]}, s...))
+	}
+	return append(r, subsets(shares[1:], k)...)
+}
+
+// testFrostDealer tests signing by every subset of at least threshold
+// shares of secret.
+func testFrostDealer(t *testing.T, secret []byte, threshold, n int, msg []byte) {
+	shares, err := FrostDealer(secret, threshold, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pub := PrivKeyFromBytes(S256(), secret)
+	for _, share := range shares {
+		if !share.PubKey().IsEqual(pub) {
+			t.Fatal("group public key should be secret*G")
+		}
+		if !share.Verify() {
+			t.Errorf("share %d should be valid", share.ID)
+		}
+	}
+	for k := threshold; k <= n; k++ {
+		for _, signers := range subsets(shares, k) {
+			sig := frostSign(t, signers, msg)
+			if !VerifySchnorr(pub.SerializeXOnly(), msg, sig) {
+				t.Errorf("%d-of-%d: signature by %d signers should verify",
+					threshold, n, k)
+			}
+		}
+	}
+}
+
+// TestFrostDealer tests signing by every subset of the threshold with keys
+// from the trusted dealer.
+func TestFrostDealer(t *testing.T) {
+	msg := TaggedHash("test", []byte("frost"))
+	// The group public keys of the secret and its negation have the y
+	// coordinates of different parities.
+	secret := decodeHex("B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF")
+	neg := new(big.Int).Sub(S256().N, new(big.Int).SetBytes(secret))
+	for _, secret := range [][]byte{secret, scalarBytes(neg)} {
+		for _, tn := range [][2]int{{1, 1}, {2, 3}, {3, 5}} {
+			testFrostDealer(t, secret, tn[0], tn[1], msg)
+		}
+	}
+
+	tampered := *(mustDeal(t, 2, 3)[1])
+	tampered.Secret = new(big.Int).Add(tampered.Secret, big.NewInt(1))
+	if tampered.Verify() {
+		t.Error("tampered share should not be valid")
+	}
+	for _, tn := range [][2]int{{0, 3}, {4, 3}} {
+		if _, err := FrostDealer(nil, tn[0], tn[1]); err != ErrFrostThreshold {
+			t.Errorf("%v: unexpected error %v", tn, err)
+		}
+	}
+}
+
+func mustDeal(t *testing.T, threshold, n int) []*FrostKeyShare {
+	shares, err := FrostDealer(nil, threshold, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return shares
+}
+
+// runDKG runs the distributed key generation of n participants.
+func runDKG(t *testing.T, threshold, n int) ([]*FrostDKG, []*FrostDKGPackage) {
+	dkgs := make([]*FrostDKG, n)
+	pkgs := make([]*FrostDKGPackage, n)
+	for i := range dkgs {
+		var err error
+		dkgs[i], pkgs[i], err = NewFrostDKG(uint32(i+1), threshold, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dkgs, pkgs
+}
+
+// TestFrostDKG tests signing with keys from the distributed key generation.
+func TestFrostDKG(t *testing.T) {
+	const threshold, n = 3, 5
+	dkgs, pkgs := runDKG(t, threshold, n)
+	received := make([]map[uint32]*big.Int, n)
+	for i := range received {
+		received[i] = make(map[uint32]*big.Int)
+	}
+	for i, d := range dkgs {
+		sent, err := d.Shares(pkgs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for to, s := range sent {
+			received[to-1][uint32(i+1)] = s
+		}
+	}
+	shares := make([]*FrostKeyShare, n)
+	for i, d := range dkgs {
+		var err error
+		shares[i], err = d.Finalize(pkgs, received[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !shares[i].PubKey().IsEqual(shares[0].PubKey()) {
+			t.Fatal("all participants should have the same group key")
+		}
+	}
+
+	// The group secret is the sum of the constant terms.
+	secret := new(big.Int)
+	for _, d := range dkgs {
+		secret.Add(secret, d.poly[0])
+	}
+	if !mulBase(secret).pubKey().IsEqual(shares[0].PubKey()) {
+		t.Error("group public key mismatch")
+	}
+
+	msg := TaggedHash("test", []byte("dkg"))
+	sig := frostSign(t, shares[1:4], msg)
+	if !VerifySchnorr(shares[0].PubKey().SerializeXOnly(), msg, sig) {
+		t.Error("signature should verify")
+	}
+	if _, err := NewFrostSession(shares[0].Commitment, nil, msg); err != ErrFrostNotEnoughSigners {
+		t.Errorf("unexpected error %v", err)
+	}
+	_, c1, _ := FrostNonceGen(shares[0])
+	_, c2, _ := FrostNonceGen(shares[1])
+	if _, err := NewFrostSession(shares[0].Commitment,
+		[]*FrostNonceCommitment{c1, c2}, msg); err != ErrFrostNotEnoughSigners {
+		t.Errorf("2 signers should not reach the threshold: %v", err)
+	}
+}
+
+// TestFrostIdentifiableAbort tests that misbehaving participants are named
+// in the errors.
+func TestFrostIdentifiableAbort(t *testing.T) {
+	// A wrong proof of knowledge.
+	dkgs, pkgs := runDKG(t, 2, 3)
+	bad := *pkgs[2]
+	bad.Proof = append([]byte{}, bad.Proof...)
+	bad.Proof[63] ^= 1
+	_, err := dkgs[0].Shares([]*FrostDKGPackage{pkgs[0], pkgs[1], &bad})
+	if !isContributionError(err, 3, "commitment") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// A share not matching the commitment of the sender.
+	received := make(map[uint32]*big.Int)
+	for i, d := range dkgs[1:] {
+		sent, err := d.Shares(pkgs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		received[uint32(i+2)] = sent[1]
+	}
+	received[2] = new(big.Int).Add(received[2], big.NewInt(1))
+	if _, err := dkgs[0].Finalize(pkgs, received); !isContributionError(err, 2, "share") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := dkgs[0].Finalize(pkgs[:2], received); err != ErrFrostNotEnoughSigners {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// A wrong signature share.
+	shares := mustDeal(t, 2, 3)
+	msg := TaggedHash("test", []byte("abort"))
+	n1, c1, _ := FrostNonceGen(shares[0])
+	n3, c3, _ := FrostNonceGen(shares[2])
+	s, err := NewFrostSession(shares[0].Commitment,
+		[]*FrostNonceCommitment{c3, c1}, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z1, err := s.Sign(n1, shares[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Sign(n1, shares[0]); err != ErrFrostNonceUsed {
+		t.Errorf("reusing the nonce should fail: %v", err)
+	}
+	if _, err := s.Sign(n3, shares[1]); err != ErrFrostNonceUsed {
+		t.Errorf("nonce of another signer should fail: %v", err)
+	}
+	z3, err := s.Sign(n3, shares[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong := append([]byte{}, z3...)
+	wrong[31] ^= 1
+	if s.VerifySignatureShare(3, wrong) {
+		t.Error("wrong signature share should not verify")
+	}
+	_, err = s.Aggregate(map[uint32][]byte{1: z1, 3: wrong})
+	if !isContributionError(err, 3, "psig") {
+		t.Errorf("unexpected error %v", err)
+	}
+	sig, err := s.Aggregate(map[uint32][]byte{1: z1, 3: z3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifySchnorr(shares[0].PubKey().SerializeXOnly(), msg, sig) {
+		t.Error("signature should verify")
+	}
+
+	// An invalid nonce commitment.
+	c1.E = &PublicKey{Curve: S256(), X: big.NewInt(1), Y: big.NewInt(1)}
+	_, err = NewFrostSession(shares[0].Commitment,
+		[]*FrostNonceCommitment{c1, c3}, msg)
+	if !isContributionError(err, 1, "pubnonce") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
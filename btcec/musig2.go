@@ -0,0 +1,575 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package btcec
+
+// References:
+//   [BIP327]: MuSig2 for BIP340-compatible Multi-Signatures
+//   https://github.com/bitcoin/bips/blob/master/bip-0327.mediawiki
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+const (
+	// PubNonceLen is the length of a public nonce of [BIP327], which is two
+	// compressed points.
+	PubNonceLen = 66
+
+	// SecNonceLen is the length of a secret nonce of [BIP327], which is two
+	// scalars followed by the compressed public key of the signer.
+	SecNonceLen = 97
+
+	// PartialSigLen is the length of a partial signature of [BIP327].
+	PartialSigLen = 32
+)
+
+var (
+	// ErrNoPubKeys describes an error in which no public keys are given to
+	// aggregate.
+	ErrNoPubKeys = errors.New("no public keys to aggregate")
+
+	// ErrInvalidTweak describes an error in which the tweak is not 32 bytes
+	// or is not less than the curve order.
+	ErrInvalidTweak = errors.New("the tweak must be less than n")
+
+	// ErrTweakInfinity describes an error in which tweaking the aggregate
+	// key results in the point at infinity.
+	ErrTweakInfinity = errors.New("the result of tweaking cannot be " +
+		"infinity")
+
+	// ErrSignerNotIncluded describes an error in which the public key of the
+	// signer is not one of the aggregated public keys.
+	ErrSignerNotIncluded = errors.New("the signer's pubkey must be " +
+		"included in the list of pubkeys")
+
+	// ErrInvalidSecNonce describes an error in which the secret nonce is
+	// malformed or was already used, or was not generated for the key.
+	ErrInvalidSecNonce = errors.New("secnonce is invalid")
+
+	// ErrInvalidMuSig2Input describes an error in which an input such as
+	// the message or the randomness has the wrong length.
+	ErrInvalidMuSig2Input = errors.New("invalid MuSig2 input")
+)
+
+// InvalidContributionError describes an error in which a value from another
+// participant is invalid, so that the participant can be blamed.  Signer is
+// the index of the participant in MuSig2 or the identifier in FROST, or -1
+// if the value is the aggregate nonce from the aggregator.  Contrib is the
+// kind of the value, i.e. "pubkey", "pubnonce", "aggnonce" or "psig", or
+// "commitment" or "share" in the key generation of FROST.
+type InvalidContributionError struct {
+	Signer  int
+	Contrib string
+}
+
+// Error implements the error interface.
+func (e *InvalidContributionError) Error() string {
+	if e.Signer < 0 {
+		return fmt.Sprintf("invalid %s", e.Contrib)
+	}
+	return fmt.Sprintf("invalid %s from signer %d", e.Contrib, e.Signer)
+}
+
+// point is an affine point where (0, 0) is the point at infinity, which is
+// the convention of KoblitzCurve.Add.
+type point struct {
+	x, y *big.Int
+}
+
+func (p point) isInfinity() bool {
+	return p.x.Sign() == 0 && p.y.Sign() == 0
+}
+
+func infinity() point {
+	return point{new(big.Int), new(big.Int)}
+}
+
+func addPoints(p1, p2 point) point {
+	if p1.isInfinity() {
+		return p2
+	}
+	if p2.isInfinity() {
+		return p1
+	}
+	if p1.x.Cmp(p2.x) == 0 && p1.y.Cmp(p2.y) != 0 {
+		return infinity()
+	}
+	x, y := S256().Add(p1.x, p1.y, p2.x, p2.y)
+	return point{x, y}
+}
+
+func mulPoint(p point, k *big.Int) point {
+	k = new(big.Int).Mod(k, S256().N)
+	if k.Sign() == 0 || p.isInfinity() {
+		return infinity()
+	}
+	x, y := S256().ScalarMult(p.x, p.y, k.Bytes())
+	return point{x, y}
+}
+
+func mulBase(k *big.Int) point {
+	k = new(big.Int).Mod(k, S256().N)
+	if k.Sign() == 0 {
+		return infinity()
+	}
+	x, y := S256().ScalarBaseMult(k.Bytes())
+	return point{x, y}
+}
+
+func negPoint(p point) point {
+	if p.isInfinity() {
+		return p
+	}
+	return point{p.x, new(big.Int).Sub(S256().P, p.y)}
+}
+
+func pubKeyPoint(p *PublicKey) point {
+	return point{p.X, p.Y}
+}
+
+func (p point) pubKey() *PublicKey {
+	return &PublicKey{Curve: S256(), X: p.x, Y: p.y}
+}
+
+// parseCompressedPoint parses a 33 bytes compressed point.  33 zero bytes
+// are parsed as the point at infinity if allowInfinity is true.
+func parseCompressedPoint(b []byte, allowInfinity bool) (point, error) {
+	if allowInfinity && bytes.Equal(b, make([]byte, PubKeyBytesLenCompressed)) {
+		return infinity(), nil
+	}
+	if len(b) != PubKeyBytesLenCompressed {
+		return point{}, errors.New("invalid compressed point")
+	}
+	p, err := ParsePubKey(b, S256())
+	if err != nil {
+		return point{}, err
+	}
+	return pubKeyPoint(p), nil
+}
+
+// serializeCompressedPoint serializes p in 33 bytes, where the point at
+// infinity is 33 zero bytes.
+func serializeCompressedPoint(p point) []byte {
+	if p.isInfinity() {
+		return make([]byte, PubKeyBytesLenCompressed)
+	}
+	return p.pubKey().SerializeCompressed()
+}
+
+// scalarBytes returns k as 32 bytes big endian.
+func scalarBytes(k *big.Int) []byte {
+	return paddedAppend(32, nil, k.Bytes())
+}
+
+// hashToScalar returns the tagged hash of msgs modulo the curve order.
+func hashToScalar(tag string, msgs ...[]byte) *big.Int {
+	e := new(big.Int).SetBytes(TaggedHash(tag, msgs...))
+	return e.Mod(e, S256().N)
+}
+
+// KeySort sorts the public keys in lexicographical order of their compressed
+// serialization as defined in [BIP327] and returns them as a new slice.
+func KeySort(pubKeys []*PublicKey) []*PublicKey {
+	sorted := make([]*PublicKey, len(pubKeys))
+	copy(sorted, pubKeys)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].SerializeCompressed(),
+			sorted[j].SerializeCompressed()) < 0
+	})
+	return sorted
+}
+
+// KeyAggContext is the result of the key aggregation of [BIP327] with the
+// tweaks applied so far.
+type KeyAggContext struct {
+	pubKeys  [][]byte
+	listHash []byte
+	second   []byte
+	q        point
+	gacc     *big.Int
+	tacc     *big.Int
+}
+
+// KeyAgg aggregates the public keys in the given order into the aggregate
+// public key of [BIP327].  Use KeySort beforehand to make the result
+// independent of the order.
+func KeyAgg(pubKeys []*PublicKey) (*KeyAggContext, error) {
+	if len(pubKeys) == 0 {
+		return nil, ErrNoPubKeys
+	}
+	ctx := &KeyAggContext{
+		pubKeys: make([][]byte, len(pubKeys)),
+		second:  make([]byte, PubKeyBytesLenCompressed),
+		q:       infinity(),
+		gacc:    big.NewInt(1),
+		tacc:    new(big.Int),
+	}
+	for i, p := range pubKeys {
+		if p == nil || p.X == nil || p.Y == nil || !S256().IsOnCurve(p.X, p.Y) {
+			return nil, &InvalidContributionError{Signer: i, Contrib: "pubkey"}
+		}
+		ctx.pubKeys[i] = p.SerializeCompressed()
+	}
+	ctx.listHash = TaggedHash("KeyAgg list", ctx.pubKeys...)
+	for _, pk := range ctx.pubKeys[1:] {
+		if !bytes.Equal(pk, ctx.pubKeys[0]) {
+			ctx.second = pk
+			break
+		}
+	}
+	for _, p := range pubKeys {
+		a := ctx.coefficient(p.SerializeCompressed())
+		ctx.q = addPoints(ctx.q, mulPoint(pubKeyPoint(p), a))
+	}
+	if ctx.q.isInfinity() {
+		return nil, errors.New("aggregate public key is infinity")
+	}
+	return ctx, nil
+}
+
+// coefficient returns the key aggregation coefficient of the compressed
+// public key pk.
+func (c *KeyAggContext) coefficient(pk []byte) *big.Int {
+	if bytes.Equal(pk, c.second) {
+		return big.NewInt(1)
+	}
+	return hashToScalar("KeyAgg coefficient", c.listHash, pk)
+}
+
+// KeyAggCoeff returns the key aggregation coefficient of the public key,
+// which must be one of the aggregated keys.
+func (c *KeyAggContext) KeyAggCoeff(pubKey *PublicKey) (*big.Int, error) {
+	pk := pubKey.SerializeCompressed()
+	for _, p := range c.pubKeys {
+		if bytes.Equal(p, pk) {
+			return c.coefficient(pk), nil
+		}
+	}
+	return nil, ErrSignerNotIncluded
+}
+
+// PubKey returns the aggregate public key with the tweaks applied.
+func (c *KeyAggContext) PubKey() *PublicKey {
+	return c.q.pubKey()
+}
+
+// XOnlyPubKey returns the 32 bytes x-only aggregate public key with the
+// tweaks applied, which is the key the final signature is valid for.
+func (c *KeyAggContext) XOnlyPubKey() []byte {
+	return c.q.pubKey().SerializeXOnly()
+}
+
+// ApplyTweak returns a new context with the 32 bytes tweak added to the
+// aggregate public key.  A plain tweak is used for BIP32 derivation from
+// the aggregate key and an x-only tweak is used for the taproot output key,
+// i.e. the tweak is TaggedHash("TapTweak", XOnlyPubKey(), merkleRoot).
+func (c *KeyAggContext) ApplyTweak(tweak []byte, isXOnly bool) (*KeyAggContext, error) {
+	curve := S256()
+	if len(tweak) != 32 {
+		return nil, ErrInvalidTweak
+	}
+	t := new(big.Int).SetBytes(tweak)
+	if t.Cmp(curve.N) >= 0 {
+		return nil, ErrInvalidTweak
+	}
+	g := big.NewInt(1)
+	if isXOnly && isOdd(c.q.y) {
+		g.Sub(curve.N, g)
+	}
+	q := addPoints(mulPoint(c.q, g), mulBase(t))
+	if q.isInfinity() {
+		return nil, ErrTweakInfinity
+	}
+	n := *c
+	n.q = q
+	n.gacc = new(big.Int).Mul(g, c.gacc)
+	n.gacc.Mod(n.gacc, curve.N)
+	n.tacc = new(big.Int).Mul(g, c.tacc)
+	n.tacc.Add(n.tacc, t)
+	n.tacc.Mod(n.tacc, curve.N)
+	return &n, nil
+}
+
+// nonceHash is nonce_hash of [BIP327].
+func nonceHash(rnd, pk, aggPK []byte, i byte, msgPrefixed, extraIn []byte) *big.Int {
+	var buf bytes.Buffer
+	buf.Write(rnd)
+	buf.WriteByte(byte(len(pk)))
+	buf.Write(pk)
+	buf.WriteByte(byte(len(aggPK)))
+	buf.Write(aggPK)
+	buf.Write(msgPrefixed)
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(extraIn)))
+	buf.Write(l[:])
+	buf.Write(extraIn)
+	buf.WriteByte(i)
+	return hashToScalar("MuSig/nonce", buf.Bytes())
+}
+
+// NonceGen generates the secret and public nonces of [BIP327] for the
+// signer whose public key is pubKey.  rnd is 32 bytes of fresh random data;
+// if it is nil, it is read from crypto/rand.  Reusing rnd leaks the private
+// key, so it must not be given unless it is random and never reused.  The
+// other arguments are optional (nil) and make the nonce more robust against
+// a bad random source: secKey is the 32 bytes private key, aggPubKey is the
+// 32 bytes x-only aggregate public key, msg is the message to sign and
+// extraIn is any additional data.
+func NonceGen(rnd, secKey []byte, pubKey *PublicKey, aggPubKey, msg,
+	extraIn []byte) (secNonce, pubNonce []byte, err error) {
+
+	if rnd == nil {
+		rnd = make([]byte, 32)
+		if _, err := rand.Read(rnd); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(rnd) != 32 || (secKey != nil && len(secKey) != 32) ||
+		(aggPubKey != nil && len(aggPubKey) != XOnlyPubKeyLen) {
+		return nil, nil, ErrInvalidMuSig2Input
+	}
+	if secKey != nil {
+		r := TaggedHash("MuSig/aux", rnd)
+		for i := range r {
+			r[i] ^= secKey[i]
+		}
+		rnd = r
+	}
+	msgPrefixed := []byte{0}
+	if msg != nil {
+		var l [8]byte
+		binary.BigEndian.PutUint64(l[:], uint64(len(msg)))
+		msgPrefixed = append([]byte{1}, l[:]...)
+		msgPrefixed = append(msgPrefixed, msg...)
+	}
+	pk := pubKey.SerializeCompressed()
+	k1 := nonceHash(rnd, pk, aggPubKey, 0, msgPrefixed, extraIn)
+	k2 := nonceHash(rnd, pk, aggPubKey, 1, msgPrefixed, extraIn)
+	if k1.Sign() == 0 || k2.Sign() == 0 {
+		return nil, nil, errors.New("nonce is zero")
+	}
+
+	secNonce = make([]byte, 0, SecNonceLen)
+	secNonce = append(secNonce, scalarBytes(k1)...)
+	secNonce = append(secNonce, scalarBytes(k2)...)
+	secNonce = append(secNonce, pk...)
+	pubNonce = make([]byte, 0, PubNonceLen)
+	pubNonce = append(pubNonce, serializeCompressedPoint(mulBase(k1))...)
+	pubNonce = append(pubNonce, serializeCompressedPoint(mulBase(k2))...)
+	return secNonce, pubNonce, nil
+}
+
+// NonceAgg aggregates the public nonces of all signers into the aggregate
+// nonce of [BIP327].  It returns InvalidContributionError if a public nonce
+// is invalid.
+func NonceAgg(pubNonces [][]byte) ([]byte, error) {
+	aggNonce := make([]byte, 0, PubNonceLen)
+	for j := 0; j < 2; j++ {
+		r := infinity()
+		for i, pn := range pubNonces {
+			if len(pn) != PubNonceLen {
+				return nil, &InvalidContributionError{Signer: i, Contrib: "pubnonce"}
+			}
+			p, err := parseCompressedPoint(pn[j*33:(j+1)*33], false)
+			if err != nil {
+				return nil, &InvalidContributionError{Signer: i, Contrib: "pubnonce"}
+			}
+			r = addPoints(r, p)
+		}
+		aggNonce = append(aggNonce, serializeCompressedPoint(r)...)
+	}
+	return aggNonce, nil
+}
+
+// Session is the signing session of [BIP327] for a message, which is
+// shared by all signers after the nonces are aggregated.
+type Session struct {
+	keyAgg *KeyAggContext
+	msg    []byte
+	b      *big.Int
+	r      point
+	e      *big.Int
+}
+
+// NewSession returns the signing session of msg for the aggregate key (with
+// tweaks) and the aggregate nonce.  It returns InvalidContributionError if
+// the aggregate nonce is invalid.
+func NewSession(keyAgg *KeyAggContext, aggNonce, msg []byte) (*Session, error) {
+	if len(aggNonce) != PubNonceLen {
+		return nil, &InvalidContributionError{Signer: -1, Contrib: "aggnonce"}
+	}
+	r1, err := parseCompressedPoint(aggNonce[:33], true)
+	if err != nil {
+		return nil, &InvalidContributionError{Signer: -1, Contrib: "aggnonce"}
+	}
+	r2, err := parseCompressedPoint(aggNonce[33:], true)
+	if err != nil {
+		return nil, &InvalidContributionError{Signer: -1, Contrib: "aggnonce"}
+	}
+	qx := keyAgg.XOnlyPubKey()
+	b := hashToScalar("MuSig/noncecoef", aggNonce, qx, msg)
+	r := addPoints(r1, mulPoint(r2, b))
+	if r.isInfinity() {
+		x, y := S256().ScalarBaseMult([]byte{1})
+		r = point{x, y}
+	}
+	e := hashToScalar("BIP0340/challenge", scalarBytes(r.x), qx, msg)
+	return &Session{
+		keyAgg: keyAgg,
+		msg:    msg,
+		b:      b,
+		r:      r,
+		e:      e,
+	}, nil
+}
+
+// g returns 1 if the aggregate key has the even y coordinate, otherwise
+// n-1, multiplied by gacc.
+func (s *Session) g() *big.Int {
+	curve := S256()
+	g := new(big.Int).Set(s.keyAgg.gacc)
+	if isOdd(s.keyAgg.q.y) {
+		g.Sub(curve.N, g)
+	}
+	return g.Mod(g, curve.N)
+}
+
+// Sign returns the 32 bytes partial signature of the signer with the secret
+// nonce from NonceGen.  The first 64 bytes of secNonce are overwritten with
+// zeros so that the nonce is never reused, which would leak the private
+// key.
+func (s *Session) Sign(secNonce []byte, priv *PrivateKey) ([]byte, error) {
+	curve := S256()
+	if len(secNonce) != SecNonceLen {
+		return nil, ErrInvalidSecNonce
+	}
+	k1 := new(big.Int).SetBytes(secNonce[:32])
+	k2 := new(big.Int).SetBytes(secNonce[32:64])
+	if k1.Sign() == 0 || k1.Cmp(curve.N) >= 0 ||
+		k2.Sign() == 0 || k2.Cmp(curve.N) >= 0 {
+		return nil, ErrInvalidSecNonce
+	}
+	d := new(big.Int).Set(priv.D)
+	if d.Sign() == 0 || d.Cmp(curve.N) >= 0 {
+		return nil, ErrInvalidSchnorrKey
+	}
+	pub := mulBase(d).pubKey()
+	if !bytes.Equal(pub.SerializeCompressed(), secNonce[64:]) {
+		return nil, ErrInvalidSecNonce
+	}
+	a, err := s.keyAgg.KeyAggCoeff(pub)
+	if err != nil {
+		return nil, err
+	}
+	for i := range secNonce[:64] {
+		secNonce[i] = 0
+	}
+	if isOdd(s.r.y) {
+		k1.Sub(curve.N, k1)
+		k2.Sub(curve.N, k2)
+	}
+
+	// s = k1 + b*k2 + e*a*g*d mod n
+	d.Mul(d, s.g())
+	d.Mul(d, a)
+	d.Mul(d, s.e)
+	sig := k2.Mul(k2, s.b)
+	sig.Add(sig, k1)
+	sig.Add(sig, d)
+	sig.Mod(sig, curve.N)
+	return scalarBytes(sig), nil
+}
+
+// VerifyPartialSig returns true if psig is the valid partial signature of
+// the signer with the public nonce and the public key in the session.
+func (s *Session) VerifyPartialSig(psig, pubNonce []byte, pubKey *PublicKey) bool {
+	curve := S256()
+	if len(psig) != PartialSigLen || len(pubNonce) != PubNonceLen {
+		return false
+	}
+	sig := new(big.Int).SetBytes(psig)
+	if sig.Cmp(curve.N) >= 0 {
+		return false
+	}
+	r1, err := parseCompressedPoint(pubNonce[:33], false)
+	if err != nil {
+		return false
+	}
+	r2, err := parseCompressedPoint(pubNonce[33:], false)
+	if err != nil {
+		return false
+	}
+	a, err := s.keyAgg.KeyAggCoeff(pubKey)
+	if err != nil {
+		return false
+	}
+	re := addPoints(r1, mulPoint(r2, s.b))
+	if isOdd(s.r.y) {
+		re = negPoint(re)
+	}
+
+	// s*G == Re + e*a*g*P
+	k := new(big.Int).Mul(s.e, a)
+	k.Mul(k, s.g())
+	want := addPoints(re, mulPoint(pubKeyPoint(pubKey), k))
+	got := mulBase(sig)
+	return got.x.Cmp(want.x) == 0 && got.y.Cmp(want.y) == 0
+}
+
+// PartialSigAgg aggregates the partial signatures of all signers into the
+// 64 bytes [BIP340] Schnorr signature for the x-only aggregate public key.
+// It returns InvalidContributionError if a partial signature is out of
+// range.  It doesn't verify partial signatures, so use VerifyPartialSig to
+// find out a wrong signer if the result doesn't verify.
+func (s *Session) PartialSigAgg(psigs [][]byte) ([]byte, error) {
+	curve := S256()
+	sum := new(big.Int)
+	for i, p := range psigs {
+		si := new(big.Int).SetBytes(p)
+		if len(p) != PartialSigLen || si.Cmp(curve.N) >= 0 {
+			return nil, &InvalidContributionError{Signer: i, Contrib: "psig"}
+		}
+		sum.Add(sum, si)
+	}
+	g := big.NewInt(1)
+	if isOdd(s.keyAgg.q.y) {
+		g.Sub(curve.N, g)
+	}
+	t := g.Mul(g, s.e)
+	t.Mul(t, s.keyAgg.tacc)
+	sum.Add(sum, t)
+	sum.Mod(sum, curve.N)
+	return append(scalarBytes(s.r.x), scalarBytes(sum)...), nil
+}
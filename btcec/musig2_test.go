@@ -0,0 +1,604 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package btcec
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// The test vectors are from
+// https://github.com/bitcoin/bips/tree/master/bip-0327/vectors
+
+func loadMuSig2Vectors(t *testing.T, name string, v interface{}) {
+	file, err := os.ReadFile("testdata/musig2/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(file, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// parsePubKeys parses the public keys at the indices and returns the
+// position of the first invalid key, or -1.
+func parsePubKeys(keys []string, indices []int) ([]*PublicKey, int) {
+	pubs := make([]*PublicKey, len(indices))
+	for i, idx := range indices {
+		p, err := ParsePubKey(decodeHex(keys[idx]), S256())
+		if err != nil {
+			return nil, i
+		}
+		pubs[i] = p
+	}
+	return pubs, -1
+}
+
+func selectHex(vals []string, indices []int) [][]byte {
+	bs := make([][]byte, len(indices))
+	for i, idx := range indices {
+		bs[i] = decodeHex(vals[idx])
+	}
+	return bs
+}
+
+func applyTweaks(ctx *KeyAggContext, tweaks []string, indices []int,
+	isXOnly []bool) (*KeyAggContext, error) {
+
+	for i, idx := range indices {
+		var err error
+		ctx, err = ctx.ApplyTweak(decodeHex(tweaks[idx]), isXOnly[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ctx, nil
+}
+
+func isContributionError(err error, signer int, contrib string) bool {
+	e, ok := err.(*InvalidContributionError)
+	return ok && e.Signer == signer && e.Contrib == contrib
+}
+
+// TestKeySort tests KeySort with the [BIP327] test vectors.
+func TestKeySort(t *testing.T) {
+	var v struct {
+		PubKeys       []string `json:"pubkeys"`
+		SortedPubKeys []string `json:"sorted_pubkeys"`
+	}
+	loadMuSig2Vectors(t, "key_sort_vectors.json", &v)
+	pubs, _ := parsePubKeys(v.PubKeys, []int{0, 1, 2, 3, 4})
+	for i, p := range KeySort(pubs) {
+		if !bytes.Equal(p.SerializeCompressed(), decodeHex(v.SortedPubKeys[i])) {
+			t.Errorf("key %d: got %x", i, p.SerializeCompressed())
+		}
+	}
+}
+
+// TestKeyAgg tests KeyAgg and ApplyTweak with the [BIP327] test vectors.
+func TestKeyAgg(t *testing.T) {
+	var v struct {
+		PubKeys []string `json:"pubkeys"`
+		Tweaks  []string `json:"tweaks"`
+		Valid   []struct {
+			KeyIndices []int  `json:"key_indices"`
+			Expected   string `json:"expected"`
+		} `json:"valid_test_cases"`
+		Errors []struct {
+			KeyIndices   []int  `json:"key_indices"`
+			TweakIndices []int  `json:"tweak_indices"`
+			IsXOnly      []bool `json:"is_xonly"`
+			Comment      string `json:"comment"`
+			Error        struct {
+				Type   string `json:"type"`
+				Signer int    `json:"signer"`
+			} `json:"error"`
+		} `json:"error_test_cases"`
+	}
+	loadMuSig2Vectors(t, "key_agg_vectors.json", &v)
+	for i, test := range v.Valid {
+		pubs, bad := parsePubKeys(v.PubKeys, test.KeyIndices)
+		if bad >= 0 {
+			t.Fatalf("valid %d: key %d should be valid", i, bad)
+		}
+		ctx, err := KeyAgg(pubs)
+		if err != nil {
+			t.Fatalf("valid %d: %v", i, err)
+		}
+		if !bytes.Equal(ctx.XOnlyPubKey(), decodeHex(test.Expected)) {
+			t.Errorf("valid %d: got %x", i, ctx.XOnlyPubKey())
+		}
+	}
+	for _, test := range v.Errors {
+		pubs, bad := parsePubKeys(v.PubKeys, test.KeyIndices)
+		if test.Error.Type == "invalid_contribution" {
+			if bad != test.Error.Signer {
+				t.Errorf("%s: key %d should be invalid", test.Comment,
+					test.Error.Signer)
+			}
+			continue
+		}
+		ctx, err := KeyAgg(pubs)
+		if err != nil {
+			t.Fatalf("%s: %v", test.Comment, err)
+		}
+		_, err = applyTweaks(ctx, v.Tweaks, test.TweakIndices, test.IsXOnly)
+		if err != ErrInvalidTweak && err != ErrTweakInfinity {
+			t.Errorf("%s: unexpected error %v", test.Comment, err)
+		}
+	}
+	if _, err := KeyAgg(nil); err != ErrNoPubKeys {
+		t.Errorf("no keys should be an error: %v", err)
+	}
+}
+
+// TestNonceGen tests NonceGen with the [BIP327] test vectors.
+func TestNonceGen(t *testing.T) {
+	var v struct {
+		Tests []struct {
+			Rand     string  `json:"rand_"`
+			SecKey   *string `json:"sk"`
+			PubKey   string  `json:"pk"`
+			AggPK    *string `json:"aggpk"`
+			Msg      *string `json:"msg"`
+			ExtraIn  *string `json:"extra_in"`
+			Expected string  `json:"expected"`
+		} `json:"test_cases"`
+	}
+	optional := func(s *string) []byte {
+		if s == nil {
+			return nil
+		}
+		return append([]byte{}, decodeHex(*s)...)
+	}
+	loadMuSig2Vectors(t, "nonce_gen_vectors.json", &v)
+	for i, test := range v.Tests {
+		pub, err := ParsePubKey(decodeHex(test.PubKey), S256())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sec, pubNonce, err := NonceGen(decodeHex(test.Rand),
+			optional(test.SecKey), pub, optional(test.AggPK),
+			optional(test.Msg), optional(test.ExtraIn))
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if !bytes.Equal(sec, decodeHex(test.Expected)) {
+			t.Errorf("test %d: got %x", i, sec)
+		}
+		_, p1 := PrivKeyFromBytes(S256(), sec[:32])
+		_, p2 := PrivKeyFromBytes(S256(), sec[32:64])
+		want := append(p1.SerializeCompressed(), p2.SerializeCompressed()...)
+		if !bytes.Equal(pubNonce, want) {
+			t.Errorf("test %d: public nonce mismatch -- got %x", i, pubNonce)
+		}
+	}
+}
+
+// TestNonceAgg tests NonceAgg with the [BIP327] test vectors.
+func TestNonceAgg(t *testing.T) {
+	var v struct {
+		PubNonces []string `json:"pnonces"`
+		Valid     []struct {
+			Indices  []int  `json:"pnonce_indices"`
+			Expected string `json:"expected"`
+		} `json:"valid_test_cases"`
+		Errors []struct {
+			Indices []int  `json:"pnonce_indices"`
+			Comment string `json:"comment"`
+			Error   struct {
+				Signer  int    `json:"signer"`
+				Contrib string `json:"contrib"`
+			} `json:"error"`
+		} `json:"error_test_cases"`
+	}
+	loadMuSig2Vectors(t, "nonce_agg_vectors.json", &v)
+	for i, test := range v.Valid {
+		agg, err := NonceAgg(selectHex(v.PubNonces, test.Indices))
+		if err != nil {
+			t.Fatalf("valid %d: %v", i, err)
+		}
+		if !bytes.Equal(agg, decodeHex(test.Expected)) {
+			t.Errorf("valid %d: got %x", i, agg)
+		}
+	}
+	for _, test := range v.Errors {
+		_, err := NonceAgg(selectHex(v.PubNonces, test.Indices))
+		if !isContributionError(err, test.Error.Signer, test.Error.Contrib) {
+			t.Errorf("%s: unexpected error %v", test.Comment, err)
+		}
+	}
+}
+
+// TestMuSig2SignVerify tests Sign and VerifyPartialSig with the [BIP327]
+// test vectors.
+func TestMuSig2SignVerify(t *testing.T) {
+	var v struct {
+		SecKey    string   `json:"sk"`
+		PubKeys   []string `json:"pubkeys"`
+		SecNonces []string `json:"secnonces"`
+		PubNonces []string `json:"pnonces"`
+		AggNonces []string `json:"aggnonces"`
+		Msgs      []string `json:"msgs"`
+		Valid     []struct {
+			KeyIndices    []int  `json:"key_indices"`
+			NonceIndices  []int  `json:"nonce_indices"`
+			AggNonceIndex int    `json:"aggnonce_index"`
+			MsgIndex      int    `json:"msg_index"`
+			SignerIndex   int    `json:"signer_index"`
+			Expected      string `json:"expected"`
+		} `json:"valid_test_cases"`
+		SignErrors []struct {
+			KeyIndices    []int  `json:"key_indices"`
+			AggNonceIndex int    `json:"aggnonce_index"`
+			MsgIndex      int    `json:"msg_index"`
+			SecNonceIndex int    `json:"secnonce_index"`
+			Comment       string `json:"comment"`
+			Error         struct {
+				Type    string `json:"type"`
+				Signer  *int   `json:"signer"`
+				Contrib string `json:"contrib"`
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"sign_error_test_cases"`
+		VerifyFails []struct {
+			Sig          string `json:"sig"`
+			KeyIndices   []int  `json:"key_indices"`
+			NonceIndices []int  `json:"nonce_indices"`
+			MsgIndex     int    `json:"msg_index"`
+			SignerIndex  int    `json:"signer_index"`
+			Comment      string `json:"comment"`
+		} `json:"verify_fail_test_cases"`
+		VerifyErrors []struct {
+			Sig          string `json:"sig"`
+			KeyIndices   []int  `json:"key_indices"`
+			NonceIndices []int  `json:"nonce_indices"`
+			MsgIndex     int    `json:"msg_index"`
+			SignerIndex  int    `json:"signer_index"`
+			Comment      string `json:"comment"`
+			Error        struct {
+				Signer  int    `json:"signer"`
+				Contrib string `json:"contrib"`
+			} `json:"error"`
+		} `json:"verify_error_test_cases"`
+	}
+	loadMuSig2Vectors(t, "sign_verify_vectors.json", &v)
+	priv, _ := PrivKeyFromBytes(S256(), decodeHex(v.SecKey))
+
+	for i, test := range v.Valid {
+		pubs, _ := parsePubKeys(v.PubKeys, test.KeyIndices)
+		ctx, err := KeyAgg(pubs)
+		if err != nil {
+			t.Fatalf("valid %d: %v", i, err)
+		}
+		pubNonces := selectHex(v.PubNonces, test.NonceIndices)
+		aggNonce := decodeHex(v.AggNonces[test.AggNonceIndex])
+		agg, err := NonceAgg(pubNonces)
+		if err != nil || !bytes.Equal(agg, aggNonce) {
+			t.Errorf("valid %d: aggregate nonce mismatch -- got %x (%v)",
+				i, agg, err)
+		}
+		s, err := NewSession(ctx, aggNonce, decodeHex(v.Msgs[test.MsgIndex]))
+		if err != nil {
+			t.Fatalf("valid %d: %v", i, err)
+		}
+		secNonce := decodeHex(v.SecNonces[0])
+		psig, err := s.Sign(secNonce, priv)
+		if err != nil {
+			t.Fatalf("valid %d: %v", i, err)
+		}
+		if !bytes.Equal(psig, decodeHex(test.Expected)) {
+			t.Errorf("valid %d: got %x", i, psig)
+		}
+		if !s.VerifyPartialSig(psig, pubNonces[test.SignerIndex],
+			pubs[test.SignerIndex]) {
+			t.Errorf("valid %d: partial signature should verify", i)
+		}
+		if _, err := s.Sign(secNonce, priv); err != ErrInvalidSecNonce {
+			t.Errorf("valid %d: reusing the nonce should fail: %v", i, err)
+		}
+	}
+
+	for _, test := range v.SignErrors {
+		pubs, bad := parsePubKeys(v.PubKeys, test.KeyIndices)
+		if test.Error.Contrib == "pubkey" {
+			if bad != *test.Error.Signer {
+				t.Errorf("%s: key %d should be invalid", test.Comment,
+					*test.Error.Signer)
+			}
+			continue
+		}
+		ctx, err := KeyAgg(pubs)
+		if err != nil {
+			t.Fatalf("%s: %v", test.Comment, err)
+		}
+		s, err := NewSession(ctx, decodeHex(v.AggNonces[test.AggNonceIndex]),
+			decodeHex(v.Msgs[test.MsgIndex]))
+		if test.Error.Contrib == "aggnonce" {
+			if !isContributionError(err, -1, "aggnonce") {
+				t.Errorf("%s: unexpected error %v", test.Comment, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", test.Comment, err)
+		}
+		_, err = s.Sign(decodeHex(v.SecNonces[test.SecNonceIndex]), priv)
+		if err != ErrSignerNotIncluded && err != ErrInvalidSecNonce {
+			t.Errorf("%s: unexpected error %v", test.Comment, err)
+		}
+	}
+
+	for _, test := range v.VerifyFails {
+		pubs, _ := parsePubKeys(v.PubKeys, test.KeyIndices)
+		ctx, err := KeyAgg(pubs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubNonces := selectHex(v.PubNonces, test.NonceIndices)
+		agg, err := NonceAgg(pubNonces)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := NewSession(ctx, agg, decodeHex(v.Msgs[test.MsgIndex]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.VerifyPartialSig(decodeHex(test.Sig), pubNonces[test.SignerIndex],
+			pubs[test.SignerIndex]) {
+			t.Errorf("%s: partial signature should not verify", test.Comment)
+		}
+	}
+
+	for _, test := range v.VerifyErrors {
+		_, bad := parsePubKeys(v.PubKeys, test.KeyIndices)
+		if test.Error.Contrib == "pubkey" {
+			if bad != test.Error.Signer {
+				t.Errorf("%s: key %d should be invalid", test.Comment,
+					test.Error.Signer)
+			}
+			continue
+		}
+		_, err := NonceAgg(selectHex(v.PubNonces, test.NonceIndices))
+		if !isContributionError(err, test.Error.Signer, test.Error.Contrib) {
+			t.Errorf("%s: unexpected error %v", test.Comment, err)
+		}
+	}
+}
+
+// TestMuSig2Tweak tests signing for tweaked aggregate keys with the [BIP327]
+// test vectors.
+func TestMuSig2Tweak(t *testing.T) {
+	var v struct {
+		SecKey    string   `json:"sk"`
+		PubKeys   []string `json:"pubkeys"`
+		SecNonce  string   `json:"secnonce"`
+		PubNonces []string `json:"pnonces"`
+		AggNonce  string   `json:"aggnonce"`
+		Tweaks    []string `json:"tweaks"`
+		Msg       string   `json:"msg"`
+		Valid     []struct {
+			KeyIndices   []int  `json:"key_indices"`
+			NonceIndices []int  `json:"nonce_indices"`
+			TweakIndices []int  `json:"tweak_indices"`
+			IsXOnly      []bool `json:"is_xonly"`
+			SignerIndex  int    `json:"signer_index"`
+			Expected     string `json:"expected"`
+			Comment      string `json:"comment"`
+		} `json:"valid_test_cases"`
+		Errors []struct {
+			KeyIndices   []int  `json:"key_indices"`
+			TweakIndices []int  `json:"tweak_indices"`
+			IsXOnly      []bool `json:"is_xonly"`
+			Comment      string `json:"comment"`
+		} `json:"error_test_cases"`
+	}
+	loadMuSig2Vectors(t, "tweak_vectors.json", &v)
+	priv, _ := PrivKeyFromBytes(S256(), decodeHex(v.SecKey))
+
+	for _, test := range v.Valid {
+		pubs, _ := parsePubKeys(v.PubKeys, test.KeyIndices)
+		ctx, err := KeyAgg(pubs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, err = applyTweaks(ctx, v.Tweaks, test.TweakIndices, test.IsXOnly)
+		if err != nil {
+			t.Fatalf("%s: %v", test.Comment, err)
+		}
+		s, err := NewSession(ctx, decodeHex(v.AggNonce), decodeHex(v.Msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		psig, err := s.Sign(decodeHex(v.SecNonce), priv)
+		if err != nil {
+			t.Fatalf("%s: %v", test.Comment, err)
+		}
+		if !bytes.Equal(psig, decodeHex(test.Expected)) {
+			t.Errorf("%s: got %x", test.Comment, psig)
+		}
+		pubNonces := selectHex(v.PubNonces, test.NonceIndices)
+		if !s.VerifyPartialSig(psig, pubNonces[test.SignerIndex],
+			pubs[test.SignerIndex]) {
+			t.Errorf("%s: partial signature should verify", test.Comment)
+		}
+	}
+	for _, test := range v.Errors {
+		pubs, _ := parsePubKeys(v.PubKeys, test.KeyIndices)
+		ctx, err := KeyAgg(pubs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = applyTweaks(ctx, v.Tweaks, test.TweakIndices, test.IsXOnly)
+		if err != ErrInvalidTweak {
+			t.Errorf("%s: unexpected error %v", test.Comment, err)
+		}
+	}
+}
+
+// TestPartialSigAgg tests PartialSigAgg with the [BIP327] test vectors.
+func TestPartialSigAgg(t *testing.T) {
+	type testCase struct {
+		AggNonce     string `json:"aggnonce"`
+		NonceIndices []int  `json:"nonce_indices"`
+		KeyIndices   []int  `json:"key_indices"`
+		TweakIndices []int  `json:"tweak_indices"`
+		IsXOnly      []bool `json:"is_xonly"`
+		PsigIndices  []int  `json:"psig_indices"`
+		Expected     string `json:"expected"`
+		Comment      string `json:"comment"`
+		Error        struct {
+			Signer int `json:"signer"`
+		} `json:"error"`
+	}
+	var v struct {
+		PubKeys   []string   `json:"pubkeys"`
+		PubNonces []string   `json:"pnonces"`
+		Tweaks    []string   `json:"tweaks"`
+		Psigs     []string   `json:"psigs"`
+		Msg       string     `json:"msg"`
+		Valid     []testCase `json:"valid_test_cases"`
+		Errors    []testCase `json:"error_test_cases"`
+	}
+	loadMuSig2Vectors(t, "sig_agg_vectors.json", &v)
+	session := func(test testCase) *Session {
+		pubs, _ := parsePubKeys(v.PubKeys, test.KeyIndices)
+		ctx, err := KeyAgg(pubs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, err = applyTweaks(ctx, v.Tweaks, test.TweakIndices, test.IsXOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		aggNonce := decodeHex(test.AggNonce)
+		agg, err := NonceAgg(selectHex(v.PubNonces, test.NonceIndices))
+		if err != nil || !bytes.Equal(agg, aggNonce) {
+			t.Errorf("aggregate nonce mismatch -- got %x (%v)", agg, err)
+		}
+		s, err := NewSession(ctx, aggNonce, decodeHex(v.Msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	for i, test := range v.Valid {
+		s := session(test)
+		sig, err := s.PartialSigAgg(selectHex(v.Psigs, test.PsigIndices))
+		if err != nil {
+			t.Fatalf("valid %d: %v", i, err)
+		}
+		if !bytes.Equal(sig, decodeHex(test.Expected)) {
+			t.Errorf("valid %d: got %x", i, sig)
+		}
+		if !VerifySchnorr(s.keyAgg.XOnlyPubKey(), decodeHex(v.Msg), sig) {
+			t.Errorf("valid %d: signature should verify", i)
+		}
+	}
+	for _, test := range v.Errors {
+		_, err := session(test).PartialSigAgg(selectHex(v.Psigs, test.PsigIndices))
+		if !isContributionError(err, test.Error.Signer, "psig") {
+			t.Errorf("%s: unexpected error %v", test.Comment, err)
+		}
+	}
+}
+
+// TestMuSig2Taproot tests a 3-of-3 key path spend, where the aggregate key
+// is tweaked to the taproot output key without a script tree.
+func TestMuSig2Taproot(t *testing.T) {
+	privs := make([]*PrivateKey, 3)
+	pubs := make([]*PublicKey, 3)
+	for i := range privs {
+		var err error
+		privs[i], err = NewPrivateKey(S256())
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubs[i] = privs[i].PubKey()
+	}
+	pubs = KeySort(pubs)
+	ctx, err := KeyAgg(pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	internal := ctx.XOnlyPubKey()
+	ctx, err = ctx.ApplyTweak(TaggedHash("TapTweak", internal), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := TaggedHash("test", []byte("sighash"))
+
+	secNonces := make([][]byte, 3)
+	pubNonces := make([][]byte, 3)
+	for i, priv := range privs {
+		secNonces[i], pubNonces[i], err = NonceGen(nil, scalarBytes(priv.D),
+			priv.PubKey(), ctx.XOnlyPubKey(), msg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	aggNonce, err := NonceAgg(pubNonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSession(ctx, aggNonce, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	psigs := make([][]byte, 3)
+	for i, priv := range privs {
+		psigs[i], err = s.Sign(secNonces[i], priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !s.VerifyPartialSig(psigs[i], pubNonces[i], priv.PubKey()) {
+			t.Errorf("partial signature %d should verify", i)
+		}
+	}
+	if s.VerifyPartialSig(psigs[0], pubNonces[1], privs[1].PubKey()) {
+		t.Error("partial signature of another signer should not verify")
+	}
+	sig, err := s.PartialSigAgg(psigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifySchnorr(ctx.XOnlyPubKey(), msg, sig) {
+		t.Error("aggregate signature should verify")
+	}
+	if VerifySchnorr(internal, msg, sig) {
+		t.Error("signature should not verify for the internal key")
+	}
+	other, _ := NewPrivateKey(S256())
+	if _, err := s.Sign(secNonces[0], other); err != ErrInvalidSecNonce {
+		t.Errorf("signing with another key should fail: %v", err)
+	}
+}
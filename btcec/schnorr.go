@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package btcec
+
+// References:
+//   [BIP340]: Schnorr Signatures for secp256k1
+//   https://github.com/bitcoin/bips/blob/master/bip-0340.mediawiki
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+const (
+	// XOnlyPubKeyLen is the length of a x-only public key of [BIP340].
+	XOnlyPubKeyLen = 32
+
+	// SchnorrSigLen is the length of a Schnorr signature of [BIP340].
+	SchnorrSigLen = 64
+)
+
+var (
+	// ErrInvalidXOnlyPubKey describes an error in which the x-only public
+	// key is not 32 bytes or is not the x coordinate of a point on the
+	// curve.
+	ErrInvalidXOnlyPubKey = errors.New("invalid x-only public key")
+
+	// ErrInvalidSchnorrInput describes an error in which the message or the
+	// auxiliary random data to sign is not 32 bytes.
+	ErrInvalidSchnorrInput = errors.New("message and auxiliary random " +
+		"data must be 32 bytes")
+
+	// ErrInvalidSchnorrKey describes an error in which the private key is
+	// out of range [1, n-1].
+	ErrInvalidSchnorrKey = errors.New("private key is out of range")
+)
+
+// TaggedHash returns the tagged hash of msgs defined in [BIP340], which is
+// sha256(sha256(tag) || sha256(tag) || msgs...).
+func TaggedHash(tag string, msgs ...[]byte) []byte {
+	th := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(th[:])
+	h.Write(th[:])
+	for _, m := range msgs {
+		h.Write(m)
+	}
+	return h.Sum(nil)
+}
+
+// ParseXOnlyPubKey parses a 32 bytes x-only public key of [BIP340] and
+// returns the point with the even y coordinate.
+func ParseXOnlyPubKey(pubKeyStr []byte) (*PublicKey, error) {
+	if len(pubKeyStr) != XOnlyPubKeyLen {
+		return nil, ErrInvalidXOnlyPubKey
+	}
+	curve := S256()
+	x := new(big.Int).SetBytes(pubKeyStr)
+	if x.Cmp(curve.P) >= 0 {
+		return nil, ErrInvalidXOnlyPubKey
+	}
+	y, err := decompressPoint(curve, x, false)
+	if err != nil || !curve.IsOnCurve(x, y) {
+		return nil, ErrInvalidXOnlyPubKey
+	}
+	return &PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// SerializeXOnly serializes the public key in the 32 bytes x-only format of
+// [BIP340], which is the x coordinate only.
+func (p *PublicKey) SerializeXOnly() []byte {
+	return paddedAppend(XOnlyPubKeyLen, nil, p.X.Bytes())
+}
+
+// SignSchnorr signs the 32 bytes msg with the private key and returns the 64
+// bytes Schnorr signature defined in [BIP340].  auxRand is 32 bytes of fresh
+// random data, which is mixed into the nonce as a protection against side
+// channel attacks.
+func SignSchnorr(priv *PrivateKey, msg, auxRand []byte) ([]byte, error) {
+	if len(msg) != 32 || len(auxRand) != 32 {
+		return nil, ErrInvalidSchnorrInput
+	}
+	curve := S256()
+	d := new(big.Int).Set(priv.D)
+	if d.Sign() == 0 || d.Cmp(curve.N) >= 0 {
+		return nil, ErrInvalidSchnorrKey
+	}
+
+	// Use the private key whose public key has the even y coordinate.
+	px, py := curve.ScalarBaseMult(paddedAppend(32, nil, d.Bytes()))
+	if isOdd(py) {
+		d.Sub(curve.N, d)
+	}
+	pBytes := paddedAppend(32, nil, px.Bytes())
+
+	// t = bytes(d) xor hash_BIP0340/aux(a)
+	t := TaggedHash("BIP0340/aux", auxRand)
+	dBytes := paddedAppend(32, nil, d.Bytes())
+	for i := range t {
+		t[i] ^= dBytes[i]
+	}
+	k := new(big.Int).SetBytes(TaggedHash("BIP0340/nonce", t, pBytes, msg))
+	k.Mod(k, curve.N)
+	if k.Sign() == 0 {
+		return nil, errors.New("nonce is zero")
+	}
+	rx, ry := curve.ScalarBaseMult(paddedAppend(32, nil, k.Bytes()))
+	if isOdd(ry) {
+		k.Sub(curve.N, k)
+	}
+	rBytes := paddedAppend(32, nil, rx.Bytes())
+
+	// s = k + e*d mod n
+	e := new(big.Int).SetBytes(TaggedHash("BIP0340/challenge", rBytes,
+		pBytes, msg))
+	e.Mod(e, curve.N)
+	s := e.Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, curve.N)
+
+	sig := paddedAppend(32, rBytes, s.Bytes())
+	if !VerifySchnorr(pBytes, msg, sig) {
+		return nil, errors.New("created signature does not verify")
+	}
+	return sig, nil
+}
+
+// VerifySchnorr returns true if sig is the valid [BIP340] Schnorr signature
+// of the 32 bytes msg for the x-only public key pubKey.
+func VerifySchnorr(pubKey, msg, sig []byte) bool {
+	if len(msg) != 32 || len(sig) != SchnorrSigLen {
+		return false
+	}
+	p, err := ParseXOnlyPubKey(pubKey)
+	if err != nil {
+		return false
+	}
+	curve := S256()
+	r := new(big.Int).SetBytes(sig[:32])
+	if r.Cmp(curve.P) >= 0 {
+		return false
+	}
+	s := new(big.Int).SetBytes(sig[32:])
+	if s.Cmp(curve.N) >= 0 {
+		return false
+	}
+	e := new(big.Int).SetBytes(TaggedHash("BIP0340/challenge", sig[:32],
+		pubKey, msg))
+	e.Mod(e, curve.N)
+
+	// R = s*G - e*P
+	sx, sy := curve.ScalarBaseMult(sig[32:])
+	e.Sub(curve.N, e)
+	ex, ey := curve.ScalarMult(p.X, p.Y, e.Bytes())
+	rx, ry := curve.Add(sx, sy, ex, ey)
+	if rx.Sign() == 0 && ry.Sign() == 0 {
+		return false
+	}
+	return !isOdd(ry) && rx.Cmp(r) == 0
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+// Package cashaddr implements the CashAddr address format of Bitcoin Cash.
+package cashaddr
+
+// References:
+//   [CashAddr]: Address format for Bitcoin Cash
+//   https://github.com/bitcoincashorg/bitcoincash.org/blob/master/spec/cashaddr.md
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bitgoin/address/bech32"
+)
+
+const (
+	charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	//checksumLength is the number of 5 bit groups in the checksum.
+	checksumLength = 8
+)
+
+//Address types in the version byte.
+const (
+	//P2KH is the type of pay-to-pubkey-hash addresses.
+	P2KH byte = 0
+	//P2SH is the type of pay-to-script-hash addresses.
+	P2SH byte = 1
+)
+
+var (
+	//ErrInvalidChecksum is returned when the checksum of the address is
+	//wrong.
+	ErrInvalidChecksum = errors.New("invalid cashaddr checksum")
+
+	//ErrMixedCase is returned when the address contains both upper and lower
+	//case characters.
+	ErrMixedCase = errors.New("cashaddr string has mixed case")
+
+	//ErrInvalidPrefix is returned when the prefix is empty or has invalid
+	//characters.
+	ErrInvalidPrefix = errors.New("invalid cashaddr prefix")
+
+	//ErrInvalidHashSize is returned when the size of the hash doesn't match
+	//the size in the version byte or is not one of the allowed sizes.
+	ErrInvalidHashSize = errors.New("invalid cashaddr hash size")
+
+	//ErrInvalidType is returned when the type doesn't fit in the version
+	//byte.
+	ErrInvalidType = errors.New("invalid cashaddr type")
+)
+
+//hashSizes are the hash sizes in bytes indexed by the size bits of the
+//version byte.
+var hashSizes = [8]int{20, 24, 28, 32, 40, 48, 56, 64}
+
+var generator = [5]uint64{0x98f2bc8e61, 0x79b76d99e2, 0xf33e5fb3c4, 0xae2eabe2a8, 0x1e4f43e470}
+
+func polymod(values []byte) uint64 {
+	c := uint64(1)
+	for _, v := range values {
+		c0 := c >> 35
+		c = (c&0x07ffffffff)<<5 ^ uint64(v)
+		for i := uint(0); i < 5; i++ {
+			if (c0>>i)&1 == 1 {
+				c ^= generator[i]
+			}
+		}
+	}
+	return c
+}
+
+func prefixExpand(prefix string) []byte {
+	r := make([]byte, 0, len(prefix)+1)
+	for i := 0; i < len(prefix); i++ {
+		r = append(r, prefix[i]&31)
+	}
+	return append(r, 0)
+}
+
+func checkPrefix(prefix string) error {
+	if prefix == "" {
+		return ErrInvalidPrefix
+	}
+	for i := 0; i < len(prefix); i++ {
+		c := prefix[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return ErrInvalidPrefix
+		}
+	}
+	return nil
+}
+
+//Encode encodes hash with the address type typ to a CashAddr address with
+//prefix such as "bitcoincash".
+func Encode(prefix string, typ byte, hash []byte) (string, error) {
+	prefix = strings.ToLower(prefix)
+	if err := checkPrefix(prefix); err != nil {
+		return "", err
+	}
+	if typ > 0x0f {
+		return "", ErrInvalidType
+	}
+	size := -1
+	for i, s := range hashSizes {
+		if s == len(hash) {
+			size = i
+		}
+	}
+	if size < 0 {
+		return "", ErrInvalidHashSize
+	}
+	payload := append([]byte{typ<<3 | byte(size)}, hash...)
+	data, err := bech32.ConvertBits(payload, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	values := append(prefixExpand(prefix), data...)
+	mod := polymod(append(values, make([]byte, checksumLength)...)) ^ 1
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteByte(':')
+	for _, v := range data {
+		b.WriteByte(charset[v])
+	}
+	for i := 0; i < checksumLength; i++ {
+		b.WriteByte(charset[(mod>>uint(5*(checksumLength-1-i)))&31])
+	}
+	return b.String(), nil
+}
+
+//Decode decodes the CashAddr address addr and returns its prefix, address
+//type and hash. If addr has no prefix, defaultPrefix is used to verify the
+//checksum.
+func Decode(addr, defaultPrefix string) (string, byte, []byte, error) {
+	lower := strings.ToLower(addr)
+	if lower != addr && strings.ToUpper(addr) != addr {
+		return "", 0, nil, ErrMixedCase
+	}
+	prefix, payload := strings.ToLower(defaultPrefix), lower
+	if i := strings.LastIndexByte(lower, ':'); i >= 0 {
+		prefix, payload = lower[:i], lower[i+1:]
+	}
+	if err := checkPrefix(prefix); err != nil {
+		return "", 0, nil, err
+	}
+	if len(payload) <= checksumLength {
+		return "", 0, nil, ErrInvalidHashSize
+	}
+	data := make([]byte, 0, len(payload))
+	for i := 0; i < len(payload); i++ {
+		d := strings.IndexByte(charset, payload[i])
+		if d < 0 {
+			return "", 0, nil, fmt.Errorf("invalid character %q in cashaddr", payload[i])
+		}
+		data = append(data, byte(d))
+	}
+	if polymod(append(prefixExpand(prefix), data...)) != 1 {
+		return "", 0, nil, ErrInvalidChecksum
+	}
+	b, err := bech32.ConvertBits(data[:len(data)-checksumLength], 5, 8, false)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if len(b) == 0 || b[0]&0x80 != 0 {
+		return "", 0, nil, ErrInvalidType
+	}
+	if len(b)-1 != hashSizes[b[0]&0x07] {
+		return "", 0, nil, ErrInvalidHashSize
+	}
+	return prefix, b[0] >> 3, b[1:], nil
+}
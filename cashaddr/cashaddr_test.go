@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package cashaddr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+//test vectors from the CashAddr specification.
+func TestCashAddr(t *testing.T) {
+	for _, test := range []struct {
+		addr string
+		typ  byte
+		hash string
+	}{
+		{"bitcoincash:qr6m7j9njldwwzlg9v7v53unlr4jkmx6eylep8ekg2", P2KH, "f5bf48b397dae70be82b3cca4793f8eb2b6cdac9"},
+		{"bchtest:pr6m7j9njldwwzlg9v7v53unlr4jkmx6eyvwc0uz5t", P2SH, "f5bf48b397dae70be82b3cca4793f8eb2b6cdac9"},
+		{"pref:pr6m7j9njldwwzlg9v7v53unlr4jkmx6ey65nvtks5", P2SH, "f5bf48b397dae70be82b3cca4793f8eb2b6cdac9"},
+		{"prefix:0r6m7j9njldwwzlg9v7v53unlr4jkmx6ey3qnjwsrf", 15, "f5bf48b397dae70be82b3cca4793f8eb2b6cdac9"},
+		{"bitcoincash:q9adhakpwzztepkpwp5z0dq62m6u5v5xtyj7j3h2ws4mr9g0", P2KH, "7adbf6c17084bc86c1706827b41a56f5ca32865925e946ea"},
+		{"bitcoincash:qvch8mmxy0rtfrlarg7ucrxxfzds5pamg73h7370aa87d80gyhqxq5nlegake", P2KH, "3173ef6623c6b48ffd1a3dcc0cc6489b0a07bb47a37f47cfef4fe69de825c060"},
+		{"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", P2KH, "76a04053bda0a88bda5177b86a15c3b29f559873"},
+		{"bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq", P2SH, "76a04053bda0a88bda5177b86a15c3b29f559873"},
+	} {
+		hash, err := hex.DecodeString(test.hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prefix := test.addr[:strings.IndexByte(test.addr, ':')]
+		addr, err := Encode(prefix, test.typ, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr != test.addr {
+			t.Error("invalid address", addr, "expected", test.addr)
+		}
+		for _, s := range []string{test.addr, strings.ToUpper(test.addr), test.addr[len(prefix)+1:]} {
+			p, typ, h, err := Decode(s, prefix)
+			if err != nil {
+				t.Fatal(s, err)
+			}
+			if p != prefix || typ != test.typ || !bytes.Equal(h, hash) {
+				t.Error("invalid decoded address", s, p, typ, hex.EncodeToString(h))
+			}
+		}
+	}
+}
+
+func TestCashAddrInvalid(t *testing.T) {
+	for i, test := range []struct {
+		addr   string
+		prefix string
+	}{
+		//wrong checksum.
+		{"bitcoincash:qr6m7j9njldwwzlg9v7v53unlr4jkmx6eylep8ekg3", ""},
+		//wrong default prefix.
+		{"qr6m7j9njldwwzlg9v7v53unlr4jkmx6eylep8ekg2", "bchtest"},
+		//no prefix.
+		{"qr6m7j9njldwwzlg9v7v53unlr4jkmx6eylep8ekg2", ""},
+		//mixed case.
+		{"bitcoincash:Qr6m7j9njldwwzlg9v7v53unlr4jkmx6eylep8ekg2", ""},
+		//invalid character.
+		{"bitcoincash:qr6m7j9njldwwzlg9v7v53unlr4jkmx6eylep8ekgb", ""},
+		//too short.
+		{"bitcoincash:qr6m7j9n", ""},
+	} {
+		if _, _, _, err := Decode(test.addr, test.prefix); err == nil {
+			t.Errorf("#%d: invalid address %s must not be decoded", i, test.addr)
+		}
+	}
+	if _, err := Encode("bitcoincash", P2KH, make([]byte, 21)); err != ErrInvalidHashSize {
+		t.Error("invalid hash size must not be encoded", err)
+	}
+	if _, err := Encode("bitcoincash", 16, make([]byte, 20)); err != ErrInvalidType {
+		t.Error("invalid type must not be encoded", err)
+	}
+	if _, err := Encode("bitcoin:cash", P2KH, make([]byte, 20)); err != ErrInvalidPrefix {
+		t.Error("invalid prefix must not be encoded", err)
+	}
+}
@@ -31,26 +31,145 @@ package address
 var (
 	//BitcoinMain is params for main net.
 	BitcoinMain = &Params{
+		Name:                   "bitcoin",
 		DumpedPrivateKeyHeader: []byte{128},
 		AddressHeader:          []byte{0},
 		P2SHHeader:             []byte{5},
 		HDPrivateKeyID:         []byte{0x04, 0x88, 0xad, 0xe4},
 		HDPublicKeyID:          []byte{0x04, 0x88, 0xb2, 0x1e},
+		Bech32HRP:              "bc",
+		CoinType:               0,
+		MessageMagic:           "Bitcoin Signed Message:\n",
 	}
 	//BitcoinTest is params for test net.
 	BitcoinTest = &Params{
+		Name:                   "bitcoin-testnet",
 		DumpedPrivateKeyHeader: []byte{239},
 		AddressHeader:          []byte{111},
 		P2SHHeader:             []byte{196},
 		HDPrivateKeyID:         []byte{0x04, 0x35, 0x83, 0x94},
 		HDPublicKeyID:          []byte{0x04, 0x35, 0x87, 0xcf},
+		Bech32HRP:              "tb",
+		CoinType:               1,
+		MessageMagic:           "Bitcoin Signed Message:\n",
 	}
 	//MonacoinMain is params for monacoin main net.
 	MonacoinMain = &Params{
+		Name:                   "monacoin",
 		DumpedPrivateKeyHeader: []byte{178, 176},
 		AddressHeader:          []byte{50},
 		P2SHHeader:             []byte{5},
 		HDPrivateKeyID:         []byte{0x04, 0x88, 0xad, 0xe4},
 		HDPublicKeyID:          []byte{0x04, 0x88, 0xb2, 0x1e},
+		Bech32HRP:              "mona",
+		CoinType:               22,
+		MessageMagic:           "Monacoin Signed Message:\n",
+	}
+	//BitcoinSignet is params for signet.
+	BitcoinSignet = &Params{
+		Name:                   "bitcoin-signet",
+		DumpedPrivateKeyHeader: []byte{239},
+		AddressHeader:          []byte{111},
+		P2SHHeader:             []byte{196},
+		HDPrivateKeyID:         []byte{0x04, 0x35, 0x83, 0x94},
+		HDPublicKeyID:          []byte{0x04, 0x35, 0x87, 0xcf},
+		Bech32HRP:              "tb",
+		CoinType:               1,
+		MessageMagic:           "Bitcoin Signed Message:\n",
+	}
+	//BitcoinRegtest is params for regression test mode.
+	BitcoinRegtest = &Params{
+		Name:                   "bitcoin-regtest",
+		DumpedPrivateKeyHeader: []byte{239},
+		AddressHeader:          []byte{111},
+		P2SHHeader:             []byte{196},
+		HDPrivateKeyID:         []byte{0x04, 0x35, 0x83, 0x94},
+		HDPublicKeyID:          []byte{0x04, 0x35, 0x87, 0xcf},
+		Bech32HRP:              "bcrt",
+		CoinType:               1,
+		MessageMagic:           "Bitcoin Signed Message:\n",
+	}
+	//MonacoinTest is params for monacoin test net.
+	MonacoinTest = &Params{
+		Name:                   "monacoin-testnet",
+		DumpedPrivateKeyHeader: []byte{239},
+		AddressHeader:          []byte{111},
+		P2SHHeader:             []byte{117},
+		HDPrivateKeyID:         []byte{0x04, 0x35, 0x83, 0x94},
+		HDPublicKeyID:          []byte{0x04, 0x35, 0x87, 0xcf},
+		Bech32HRP:              "tmona",
+		CoinType:               1,
+		MessageMagic:           "Monacoin Signed Message:\n",
+	}
+	//LitecoinMain is params for litecoin main net.
+	LitecoinMain = &Params{
+		Name:                   "litecoin",
+		DumpedPrivateKeyHeader: []byte{176},
+		AddressHeader:          []byte{48},
+		P2SHHeader:             []byte{50},
+		HDPrivateKeyID:         []byte{0x01, 0x9d, 0x9c, 0xfe},
+		HDPublicKeyID:          []byte{0x01, 0x9d, 0xa4, 0x62},
+		Bech32HRP:              "ltc",
+		CoinType:               2,
+		MessageMagic:           "Litecoin Signed Message:\n",
+	}
+	//LitecoinTest is params for litecoin test net.
+	LitecoinTest = &Params{
+		Name:                   "litecoin-testnet",
+		DumpedPrivateKeyHeader: []byte{239},
+		AddressHeader:          []byte{111},
+		P2SHHeader:             []byte{58},
+		HDPrivateKeyID:         []byte{0x04, 0x35, 0x83, 0x94},
+		HDPublicKeyID:          []byte{0x04, 0x35, 0x87, 0xcf},
+		Bech32HRP:              "tltc",
+		CoinType:               1,
+		MessageMagic:           "Litecoin Signed Message:\n",
+	}
+	//DogecoinMain is params for dogecoin main net, which has no segwit.
+	DogecoinMain = &Params{
+		Name:                   "dogecoin",
+		DumpedPrivateKeyHeader: []byte{158},
+		AddressHeader:          []byte{30},
+		P2SHHeader:             []byte{22},
+		HDPrivateKeyID:         []byte{0x02, 0xfa, 0xc3, 0x98},
+		HDPublicKeyID:          []byte{0x02, 0xfa, 0xca, 0xfd},
+		CoinType:               3,
+		MessageMagic:           "Dogecoin Signed Message:\n",
+	}
+	//DogecoinTest is params for dogecoin test net, which has no segwit.
+	DogecoinTest = &Params{
+		Name:                   "dogecoin-testnet",
+		DumpedPrivateKeyHeader: []byte{241},
+		AddressHeader:          []byte{113},
+		P2SHHeader:             []byte{196},
+		HDPrivateKeyID:         []byte{0x04, 0x35, 0x83, 0x94},
+		HDPublicKeyID:          []byte{0x04, 0x35, 0x87, 0xcf},
+		CoinType:               1,
+		MessageMagic:           "Dogecoin Signed Message:\n",
+	}
+	//BitcoinCashMain is params for bitcoin cash main net, which uses
+	//CashAddr instead of segwit.
+	BitcoinCashMain = &Params{
+		Name:                   "bitcoincash",
+		DumpedPrivateKeyHeader: []byte{128},
+		AddressHeader:          []byte{0},
+		P2SHHeader:             []byte{5},
+		HDPrivateKeyID:         []byte{0x04, 0x88, 0xad, 0xe4},
+		HDPublicKeyID:          []byte{0x04, 0x88, 0xb2, 0x1e},
+		CashAddrPrefix:         "bitcoincash",
+		CoinType:               145,
+		MessageMagic:           "Bitcoin Signed Message:\n",
+	}
+	//BitcoinCashTest is params for bitcoin cash test net.
+	BitcoinCashTest = &Params{
+		Name:                   "bitcoincash-testnet",
+		DumpedPrivateKeyHeader: []byte{239},
+		AddressHeader:          []byte{111},
+		P2SHHeader:             []byte{196},
+		HDPrivateKeyID:         []byte{0x04, 0x35, 0x83, 0x94},
+		HDPublicKeyID:          []byte{0x04, 0x35, 0x87, 0xcf},
+		CashAddrPrefix:         "bchtest",
+		CoinType:               1,
+		MessageMagic:           "Bitcoin Signed Message:\n",
 	}
 )
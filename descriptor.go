@@ -0,0 +1,731 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+// References:
+//   [BIP380]: Output Script Descriptors General Operation
+//   https://github.com/bitcoin/bips/blob/master/bip-0380.mediawiki
+//   [BIP381]-[BIP386]: Non-Segwit, Segwit, Multisig, combo, raw/addr and
+//   tr Output Script Descriptors
+//   https://github.com/bitcoin/bips/blob/master/bip-0386.mediawiki
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bitgoin/address/btcec"
+)
+
+var (
+	//ErrInvalidDescriptor is returned when the descriptor is malformed or
+	//uses an expression in a context where it is not allowed.
+	ErrInvalidDescriptor = errors.New("invalid descriptor")
+
+	//ErrInvalidDescriptorChecksum is returned when the checksum after '#'
+	//doesn't match the descriptor.
+	ErrInvalidDescriptorChecksum = errors.New("invalid descriptor checksum")
+
+	//ErrDescriptorIndex is returned when the index to expand a ranged
+	//descriptor is a hardened index.
+	ErrDescriptorIndex = errors.New("index of ranged descriptor must be less than 2^31")
+)
+
+const (
+	descInputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	descChecksumLen     = 8
+
+	//maxTapTreeDepth is the maximum depth of the taproot script tree.
+	maxTapTreeDepth = 128
+)
+
+var descGenerator = [5]uint64{
+	0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd,
+}
+
+func descPolymod(c uint64, v int) uint64 {
+	top := c >> 35
+	c = (c&0x7ffffffff)<<5 ^ uint64(v)
+	for i, g := range descGenerator {
+		if (top>>uint(i))&1 == 1 {
+			c ^= g
+		}
+	}
+	return c
+}
+
+//DescriptorChecksum returns the 8 characters checksum of desc, which must
+//not include "#checksum".
+func DescriptorChecksum(desc string) (string, error) {
+	c := uint64(1)
+	cls, clsCount := 0, 0
+	for _, ch := range desc {
+		pos := strings.IndexRune(descInputCharset, ch)
+		if pos < 0 {
+			return "", fmt.Errorf("%w: invalid character %q", ErrInvalidDescriptor, ch)
+		}
+		c = descPolymod(c, pos&31)
+		cls = cls*3 + pos>>5
+		if clsCount++; clsCount == 3 {
+			c = descPolymod(c, cls)
+			cls, clsCount = 0, 0
+		}
+	}
+	if clsCount > 0 {
+		c = descPolymod(c, cls)
+	}
+	for i := 0; i < descChecksumLen; i++ {
+		c = descPolymod(c, 0)
+	}
+	c ^= 1
+	b := make([]byte, descChecksumLen)
+	for i := range b {
+		b[i] = descChecksumCharset[(c>>(5*uint(descChecksumLen-1-i)))&31]
+	}
+	return string(b), nil
+}
+
+//KeyOrigin is the origin of a key in a descriptor, i.e. the fingerprint of
+//the master key and the derivation path from it.
+type KeyOrigin struct {
+	Fingerprint uint32
+	Path        DerivationPath
+}
+
+//String returns the origin in the form "d34db33f/84'/0'/0'" without
+//brackets.
+func (o *KeyOrigin) String() string {
+	return fmt.Sprintf("%08x", o.Fingerprint) + o.Path.String()[1:]
+}
+
+//range types of extended keys in descriptors.
+const (
+	rangeNone = iota
+	rangeUnhardened
+	rangeHardened
+)
+
+//descriptorKey is a KEY expression of descriptors.
+type descriptorKey struct {
+	origin *KeyOrigin
+	//pub is the fixed public key, or nil for extended keys.
+	pub *PublicKey
+	//priv is set if the key is given in WIF.
+	priv *PrivateKey
+	//xonly is true if pub is given as 32 bytes x-only key.
+	xonly  bool
+	ext    *ExtendedKey
+	path   DerivationPath
+	ranged int
+}
+
+//key contexts restricting the forms of keys.
+const (
+	keyAny = iota
+	keyCompressed
+	keyXOnly
+)
+
+//parseDescriptorKey parses the KEY expression s.
+func parseDescriptorKey(s string, ctx int, param *Params) (*descriptorKey, error) {
+	k := &descriptorKey{}
+	if strings.HasPrefix(s, "[") {
+		i := strings.IndexByte(s, ']')
+		if i < 0 {
+			return nil, fmt.Errorf("%w: unterminated key origin in %q", ErrInvalidDescriptor, s)
+		}
+		origin, err := parseKeyOrigin(s[1:i])
+		if err != nil {
+			return nil, err
+		}
+		k.origin = origin
+		s = s[i+1:]
+	}
+
+	if b, err := hex.DecodeString(s); err == nil {
+		switch {
+		case len(b) == 32 && ctx == keyXOnly:
+			k.xonly = true
+			b = append([]byte{0x02}, b...)
+		case len(b) == btcec.PubKeyBytesLenCompressed:
+		case len(b) == btcec.PubKeyBytesLenUncompressed && ctx == keyAny:
+		default:
+			return nil, fmt.Errorf("%w: public key %q is not allowed here", ErrInvalidDescriptor, s)
+		}
+		if k.pub, err = NewPublicKey(b, param); err != nil {
+			return nil, err
+		}
+		return k, nil
+	}
+
+	segs := strings.Split(s, "/")
+	ext, err := NewKeyFromString(segs[0], param)
+	if err != nil {
+		if len(segs) != 1 {
+			return nil, err
+		}
+		if k.priv, err = FromWIF(s, param); err != nil {
+			return nil, fmt.Errorf("%w: invalid key %q", ErrInvalidDescriptor, s)
+		}
+		if !k.priv.PublicKey.isCompressed && ctx != keyAny {
+			return nil, fmt.Errorf("%w: uncompressed key is not allowed here", ErrInvalidDescriptor)
+		}
+		k.pub = k.priv.PublicKey
+		return k, nil
+	}
+	k.ext = ext
+	segs = segs[1:]
+	if n := len(segs); n > 0 {
+		switch segs[n-1] {
+		case "*":
+			k.ranged = rangeUnhardened
+		case "*'", "*h", "*H":
+			k.ranged = rangeHardened
+		}
+		if k.ranged != rangeNone {
+			segs = segs[:n-1]
+		}
+	}
+	if len(segs) > 0 && (segs[0] == "m" || segs[0] == "M") {
+		return nil, fmt.Errorf("%w: invalid path in %q", ErrInvalidDescriptor, s)
+	}
+	if k.path, err = ParseDerivationPath(strings.Join(segs, "/")); err != nil {
+		return nil, err
+	}
+	if !ext.IsPrivate() {
+		hardened := k.ranged == rangeHardened
+		for _, i := range k.path {
+			hardened = hardened || i >= HardenedKeyStart
+		}
+		if hardened {
+			return nil, ErrDeriveHardFromPublic
+		}
+	}
+	return k, nil
+}
+
+//parseKeyOrigin parses the key origin without brackets.
+func parseKeyOrigin(s string) (*KeyOrigin, error) {
+	segs := strings.SplitN(s, "/", 2)
+	if len(segs[0]) != 8 {
+		return nil, fmt.Errorf("%w: invalid fingerprint in %q", ErrInvalidDescriptor, s)
+	}
+	fp, err := strconv.ParseUint(segs[0], 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid fingerprint in %q", ErrInvalidDescriptor, s)
+	}
+	o := &KeyOrigin{Fingerprint: uint32(fp), Path: DerivationPath{}}
+	if len(segs) == 2 {
+		if segs[1] == "" || segs[1][0] == 'm' || segs[1][0] == 'M' {
+			return nil, fmt.Errorf("%w: invalid path in %q", ErrInvalidDescriptor, s)
+		}
+		if o.Path, err = ParseDerivationPath(segs[1]); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+//String returns the KEY expression.
+func (k *descriptorKey) String() string {
+	var b strings.Builder
+	if k.origin != nil {
+		b.WriteString("[" + k.origin.String() + "]")
+	}
+	switch {
+	case k.priv != nil:
+		b.WriteString(k.priv.WIFAddress())
+	case k.xonly:
+		b.WriteString(hex.EncodeToString(k.pub.XOnly()))
+	case k.pub != nil:
+		b.WriteString(hex.EncodeToString(k.pub.Serialize()))
+	default:
+		b.WriteString(k.ext.String())
+		b.WriteString(k.path.String()[1:])
+		switch k.ranged {
+		case rangeUnhardened:
+			b.WriteString("/*")
+		case rangeHardened:
+			b.WriteString("/*'")
+		}
+	}
+	return b.String()
+}
+
+//pubKey returns the public key at index.
+func (k *descriptorKey) pubKey(index uint32) (*PublicKey, error) {
+	if k.pub != nil {
+		return k.pub, nil
+	}
+	path := append(DerivationPath{}, k.path...)
+	switch k.ranged {
+	case rangeUnhardened:
+		path = append(path, index)
+	case rangeHardened:
+		path = append(path, index+HardenedKeyStart)
+	}
+	ext, err := k.ext.DerivePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return ext.PubKey()
+}
+
+//script contexts of descriptors.
+const (
+	ctxTop = iota
+	ctxP2SH
+	ctxP2WSH
+	ctxTapscript
+)
+
+//descriptorNode is a SCRIPT expression of descriptors.
+type descriptorNode struct {
+	name string
+	ctx  int
+	keys []*descriptorKey
+	//m is the number of required signatures of multi and sortedmulti.
+	m    int
+	sub  *descriptorNode
+	tree *tapTree
+	addr Address
+	raw  []byte
+}
+
+//tapTree is a node of the taproot script tree, which is either a leaf or
+//a branch.
+type tapTree struct {
+	leaf        *descriptorNode
+	left, right *tapTree
+}
+
+//splitArgs splits s by commas which are not enclosed by brackets.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth--; depth < 0 {
+				return nil, fmt.Errorf("%w: unbalanced brackets", ErrInvalidDescriptor)
+			}
+		case ',':
+			if depth == 0 {
+				args = append(args, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("%w: unbalanced brackets", ErrInvalidDescriptor)
+	}
+	return append(args, s[start:]), nil
+}
+
+//parseDescriptorNode parses the SCRIPT expression s in ctx.
+func parseDescriptorNode(s string, ctx int, param *Params) (*descriptorNode, error) {
+	i := strings.IndexByte(s, '(')
+	if i <= 0 || !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("%w: %q is not a script expression", ErrInvalidDescriptor, s)
+	}
+	n := &descriptorNode{name: s[:i], ctx: ctx}
+	args, err := splitArgs(s[i+1 : len(s)-1])
+	if err != nil {
+		return nil, err
+	}
+	notAllowed := fmt.Errorf("%w: %s() is not allowed here", ErrInvalidDescriptor, n.name)
+	keyCtx := keyAny
+	switch ctx {
+	case ctxP2WSH:
+		keyCtx = keyCompressed
+	case ctxTapscript:
+		keyCtx = keyXOnly
+	}
+
+	switch n.name {
+	case "pk", "pkh", "wpkh":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: %s() takes one key", ErrInvalidDescriptor, n.name)
+		}
+		switch {
+		case n.name == "pkh" && ctx == ctxTapscript:
+			return nil, notAllowed
+		case n.name == "wpkh":
+			if ctx != ctxTop && ctx != ctxP2SH {
+				return nil, notAllowed
+			}
+			keyCtx = keyCompressed
+		}
+		k, err := parseDescriptorKey(args[0], keyCtx, param)
+		if err != nil {
+			return nil, err
+		}
+		n.keys = []*descriptorKey{k}
+	case "sh", "wsh":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: %s() takes one script", ErrInvalidDescriptor, n.name)
+		}
+		subCtx := ctxP2SH
+		if n.name == "wsh" {
+			subCtx = ctxP2WSH
+		}
+		if ctx != ctxTop && (n.name == "sh" || ctx != ctxP2SH) {
+			return nil, notAllowed
+		}
+		if n.sub, err = parseDescriptorNode(args[0], subCtx, param); err != nil {
+			return nil, err
+		}
+	case "multi", "sortedmulti":
+		if ctx == ctxTapscript {
+			return nil, notAllowed
+		}
+		if len(args) < 2 || len(args)-1 > MaxMultisigKeys {
+			return nil, fmt.Errorf("%w: %s() takes 1 to %d keys",
+				ErrInvalidDescriptor, n.name, MaxMultisigKeys)
+		}
+		m, err := strconv.Atoi(args[0])
+		if err != nil || m < 1 || m > len(args)-1 || args[0][0] == '+' {
+			return nil, fmt.Errorf("%w: invalid threshold %q", ErrInvalidDescriptor, args[0])
+		}
+		n.m = m
+		for _, a := range args[1:] {
+			k, err := parseDescriptorKey(a, keyCtx, param)
+			if err != nil {
+				return nil, err
+			}
+			n.keys = append(n.keys, k)
+		}
+	case "tr":
+		if ctx != ctxTop {
+			return nil, notAllowed
+		}
+		if len(args) != 1 && len(args) != 2 {
+			return nil, fmt.Errorf("%w: tr() takes a key and an optional tree", ErrInvalidDescriptor)
+		}
+		k, err := parseDescriptorKey(args[0], keyXOnly, param)
+		if err != nil {
+			return nil, err
+		}
+		n.keys = []*descriptorKey{k}
+		if len(args) == 2 {
+			if n.tree, err = parseTapTree(args[1], 0, param); err != nil {
+				return nil, err
+			}
+		}
+	case "addr":
+		if ctx != ctxTop {
+			return nil, notAllowed
+		}
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: addr() takes one address", ErrInvalidDescriptor)
+		}
+		if n.addr, err = ParseAddress(args[0], param); err != nil {
+			return nil, err
+		}
+	case "raw":
+		if ctx != ctxTop {
+			return nil, notAllowed
+		}
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: raw() takes one script", ErrInvalidDescriptor)
+		}
+		if n.raw, err = hex.DecodeString(args[0]); err != nil {
+			return nil, fmt.Errorf("%w: invalid hex %q", ErrInvalidDescriptor, args[0])
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown script expression %s()", ErrInvalidDescriptor, n.name)
+	}
+	return n, nil
+}
+
+//parseTapTree parses the TREE expression s at depth.
+func parseTapTree(s string, depth int, param *Params) (*tapTree, error) {
+	if depth > maxTapTreeDepth {
+		return nil, fmt.Errorf("%w: script tree is too deep", ErrInvalidDescriptor)
+	}
+	if !strings.HasPrefix(s, "{") {
+		leaf, err := parseDescriptorNode(s, ctxTapscript, param)
+		if err != nil {
+			return nil, err
+		}
+		return &tapTree{leaf: leaf}, nil
+	}
+	if !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("%w: unbalanced brackets", ErrInvalidDescriptor)
+	}
+	args, err := splitArgs(s[1 : len(s)-1])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 2 {
+		return nil, fmt.Errorf("%w: branch of script tree must have two children", ErrInvalidDescriptor)
+	}
+	t := &tapTree{}
+	if t.left, err = parseTapTree(args[0], depth+1, param); err != nil {
+		return nil, err
+	}
+	if t.right, err = parseTapTree(args[1], depth+1, param); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+//String returns the SCRIPT expression.
+func (n *descriptorNode) String() string {
+	var args []string
+	switch n.name {
+	case "sh", "wsh":
+		args = []string{n.sub.String()}
+	case "multi", "sortedmulti":
+		args = []string{strconv.Itoa(n.m)}
+	case "addr":
+		args = []string{n.addr.String()}
+	case "raw":
+		args = []string{hex.EncodeToString(n.raw)}
+	}
+	for _, k := range n.keys {
+		args = append(args, k.String())
+	}
+	if n.tree != nil {
+		args = append(args, n.tree.String())
+	}
+	return n.name + "(" + strings.Join(args, ",") + ")"
+}
+
+//String returns the TREE expression.
+func (t *tapTree) String() string {
+	if t.leaf != nil {
+		return t.leaf.String()
+	}
+	return "{" + t.left.String() + "," + t.right.String() + "}"
+}
+
+//isRange returns true if any key of the node is ranged.
+func (n *descriptorNode) isRange() bool {
+	for _, k := range n.keys {
+		if k.ranged != rangeNone {
+			return true
+		}
+	}
+	if n.sub != nil && n.sub.isRange() {
+		return true
+	}
+	return n.tree != nil && n.tree.isRange()
+}
+
+func (t *tapTree) isRange() bool {
+	if t.leaf != nil {
+		return t.leaf.isRange()
+	}
+	return t.left.isRange() || t.right.isRange()
+}
+
+//DescriptorOutput is an output expanded from a descriptor.
+type DescriptorOutput struct {
+	//ScriptPubKey is the output script.
+	ScriptPubKey []byte
+	//Address is the address of the output, or nil if the output script has
+	//no address form, such as pk(), multi() and raw().
+	Address Address
+	//RedeemScript is the redeem script of sh() descriptors.
+	RedeemScript []byte
+	//WitnessScript is the witness script of wsh() and sh(wsh()) descriptors.
+	WitnessScript []byte
+}
+
+//expand returns the output of the node at index.
+func (n *descriptorNode) expand(index uint32, param *Params) (*DescriptorOutput, error) {
+	var pubs []*PublicKey
+	for _, k := range n.keys {
+		pub, err := k.pubKey(index)
+		if err != nil {
+			return nil, err
+		}
+		pubs = append(pubs, pub)
+	}
+	out := &DescriptorOutput{}
+	var err error
+	switch n.name {
+	case "pk":
+		if n.ctx == ctxTapscript {
+			out.ScriptPubKey = append([]byte{32}, pubs[0].XOnly()...)
+		} else {
+			b := pubs[0].Serialize()
+			out.ScriptPubKey = append([]byte{byte(len(b))}, b...)
+		}
+		out.ScriptPubKey = append(out.ScriptPubKey, 0xac)
+		return out, nil
+	case "pkh":
+		out.Address, err = NewP2PKHAddress(pubs[0].AddressBytes(), param)
+	case "wpkh":
+		out.Address, err = NewP2WPKHAddress(pubs[0].WitnessProgram(), param)
+	case "sh":
+		sub, err := n.sub.expand(index, param)
+		if err != nil {
+			return nil, err
+		}
+		out.RedeemScript = sub.ScriptPubKey
+		out.WitnessScript = sub.WitnessScript
+		out.Address, err = NewP2SHAddress(AddressBytes(out.RedeemScript), param)
+		if err != nil {
+			return nil, err
+		}
+	case "wsh":
+		sub, err := n.sub.expand(index, param)
+		if err != nil {
+			return nil, err
+		}
+		out.WitnessScript = sub.ScriptPubKey
+		h := sha256.Sum256(out.WitnessScript)
+		out.Address, err = NewP2WSHAddress(h[:], param)
+		if err != nil {
+			return nil, err
+		}
+	case "multi", "sortedmulti":
+		out.ScriptPubKey, err = NewMultisigScript(n.m, pubs, n.name == "sortedmulti")
+		return out, err
+	case "tr":
+		var root []byte
+		if n.tree != nil {
+			if root, err = n.tree.hash(index, param); err != nil {
+				return nil, err
+			}
+		}
+		q, err := pubs[0].TaprootOutputKey(root)
+		if err != nil {
+			return nil, err
+		}
+		out.Address, err = NewP2TRAddress(q, param)
+		if err != nil {
+			return nil, err
+		}
+	case "addr":
+		out.Address = n.addr
+	case "raw":
+		out.ScriptPubKey = append([]byte{}, n.raw...)
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out.ScriptPubKey = out.Address.ScriptPubKey()
+	return out, nil
+}
+
+//hash returns the BIP341 tapleaf or tapbranch hash of the tree at index.
+func (t *tapTree) hash(index uint32, param *Params) ([]byte, error) {
+	if t.leaf != nil {
+		out, err := t.leaf.expand(index, param)
+		if err != nil {
+			return nil, err
+		}
+		var b bytes.Buffer
+		//leaf version of tapscript.
+		b.WriteByte(0xc0)
+		writeVarBytes(&b, out.ScriptPubKey)
+		return btcec.TaggedHash("TapLeaf", b.Bytes()), nil
+	}
+	l, err := t.left.hash(index, param)
+	if err != nil {
+		return nil, err
+	}
+	r, err := t.right.hash(index, param)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Compare(l, r) > 0 {
+		l, r = r, l
+	}
+	return btcec.TaggedHash("TapBranch", l, r), nil
+}
+
+//Descriptor is an output script descriptor.
+type Descriptor struct {
+	root  *descriptorNode
+	param *Params
+}
+
+//ParseDescriptor parses the descriptor desc of the network param.
+//The checksum after '#' is verified if present.
+func ParseDescriptor(desc string, param *Params) (*Descriptor, error) {
+	body := desc
+	if i := strings.IndexByte(desc, '#'); i >= 0 {
+		body = desc[:i]
+		sum, err := DescriptorChecksum(body)
+		if err != nil {
+			return nil, err
+		}
+		if desc[i+1:] != sum {
+			return nil, ErrInvalidDescriptorChecksum
+		}
+	} else if _, err := DescriptorChecksum(body); err != nil {
+		return nil, err
+	}
+	root, err := parseDescriptorNode(body, ctxTop, param)
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptor{root: root, param: param}, nil
+}
+
+//String returns the descriptor with its checksum.
+//Hardened derivations are always written with "'".
+func (d *Descriptor) String() string {
+	s := d.root.String()
+	sum, err := DescriptorChecksum(s)
+	if err != nil {
+		//never happens, all characters are in the charset.
+		return s
+	}
+	return s + "#" + sum
+}
+
+//IsRange returns true if the descriptor has keys ending with "/*", whose
+//outputs depend on the index of Expand.
+func (d *Descriptor) IsRange() bool {
+	return d.root.isRange()
+}
+
+//Expand returns the output of the descriptor at index, which replaces "*"
+//of ranged keys. index is ignored if the descriptor is not ranged.
+func (d *Descriptor) Expand(index uint32) (*DescriptorOutput, error) {
+	if d.IsRange() && index >= HardenedKeyStart {
+		return nil, ErrDescriptorIndex
+	}
+	return d.root.expand(index, d.param)
+}
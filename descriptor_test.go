@@ -0,0 +1,273 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+//TestDescriptorChecksum tests with the vectors of BIP380.
+func TestDescriptorChecksum(t *testing.T) {
+	for i, test := range []struct {
+		desc string
+		fail error
+	}{
+		{"raw(deadbeef)#89f8spxm", nil},
+		{"raw(deadbeef)", nil},
+		{"raw(deadbeef)#", ErrInvalidDescriptorChecksum},
+		{"raw(deadbeef)#89f8spxmx", ErrInvalidDescriptorChecksum},
+		{"raw(deadbeef)#89f8spx", ErrInvalidDescriptorChecksum},
+		{"raw(deadbeef)#89f8spxn", ErrInvalidDescriptorChecksum},
+		{"raw(deedbeef)#89f8spxm", ErrInvalidDescriptorChecksum},
+		{"raw(Ü)#00000000", ErrInvalidDescriptor},
+	} {
+		_, err := ParseDescriptor(test.desc, BitcoinMain)
+		if !errors.Is(err, test.fail) {
+			t.Errorf("#%d: error %v, expected %v", i, err, test.fail)
+		}
+	}
+	sum, err := DescriptorChecksum("raw(deadbeef)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != "89f8spxm" {
+		t.Errorf("checksum %s, expected 89f8spxm", sum)
+	}
+}
+
+//TestDescriptorExpand tests with the vectors of BIP381-BIP386.
+func TestDescriptorExpand(t *testing.T) {
+	for i, test := range []struct {
+		desc    string
+		param   *Params
+		scripts []string
+		addr    string
+	}{
+		//BIP381
+		{
+			desc:    "pk(0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798)",
+			scripts: []string{"210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798ac"},
+		},
+		{
+			desc:    "pkh(02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5)",
+			scripts: []string{"76a91406afd46bcdfd22ef94ac122aa11f241244a37ecc88ac"},
+			addr:    "1cMh228HTCiwS8ZsaakH8A8wze1JR5ZsP",
+		},
+		{
+			desc:    "pkh(xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw/1/2)",
+			scripts: []string{"76a914f833c08f02389c451ae35ec797fccf7f396616bf88ac"},
+		},
+		{
+			desc:    "pkh(xprv9s21ZrQH143K31xYSDQpPDxsXRTUcvj2iNHm5NUtrGiGG5e2DtALGdso3pGz6ssrdK4PFmM8NSpSBHNqPqm55Qn3LqFtT2emdEXVYsCzC2U/2147483647'/0)",
+			scripts: []string{"76a914ebdc90806a9c4356c1c88e42216611e1cb4c1c1788ac"},
+		},
+		{
+			desc:    "pkh([bd16bee5/2147483647']xpub69H7F5dQzmVd3vPuLKtcXJziMEQByuDidnX3YdwgtNsecY5HRGtAAQC5mXTt4dsv9RzyjgDjAQs9VGVV6ydYCHnprc9vvaA5YtqWyL6hyds/0)",
+			scripts: []string{"76a914ebdc90806a9c4356c1c88e42216611e1cb4c1c1788ac"},
+		},
+		//BIP382
+		{
+			desc:    "sh(wpkh(03fff97bd5755eeea420453a14355235d382f6472f8568a18b2f057a1460297556))",
+			scripts: []string{"a914cc6ffbc0bf31af759451068f90ba7a0272b6b33287"},
+		},
+		{
+			desc:    "wsh(pkh(02e493dbf1c10d80f3581e4904930b1404cc6c13900ee0758474fa94abe8c4cd13))",
+			scripts: []string{"0020fc5acc302aab97f821f9a61e1cc572e7968a603551e95d4ba12b51df6581482f"},
+		},
+		//BIP383 with the keys of BIP67
+		{
+			desc:    "sh(sortedmulti(2,02ff12471208c14bd580709cb2358d98975247d8765f92bc25eab3b2763ed605f8,02fe6f0a5a297eb38c391581c4413e084773ea23954d93f7753db7dc0adc188b2f))",
+			scripts: []string{"a91456be8ea93912f37685542a2a864a5600f88a675487"},
+			addr:    "39bgKC7RFbpoCRbtD5KEdkYKtNyhpsNa3Z",
+		},
+		{
+			desc:    "sh(multi(2,02fe6f0a5a297eb38c391581c4413e084773ea23954d93f7753db7dc0adc188b2f,02ff12471208c14bd580709cb2358d98975247d8765f92bc25eab3b2763ed605f8))",
+			scripts: []string{"a91456be8ea93912f37685542a2a864a5600f88a675487"},
+			addr:    "39bgKC7RFbpoCRbtD5KEdkYKtNyhpsNa3Z",
+		},
+		//BIP385
+		{
+			desc:    "raw(deadbeef)",
+			scripts: []string{"deadbeef"},
+		},
+		{
+			desc:    "addr(1PMycacnJaSqwwJqjawXBErnLsZ7RkXUAs)",
+			scripts: []string{"76a914f54a5851e9372b87810a8e60cdd2e7cfd80b6e3188ac"},
+			addr:    "1PMycacnJaSqwwJqjawXBErnLsZ7RkXUAs",
+		},
+		{
+			desc:    "addr(bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4)",
+			scripts: []string{"0014751e76e8199196d454941c45d1b3a323f1433bd6"},
+			addr:    "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+		},
+		//BIP386
+		{
+			desc:    "tr(a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd)",
+			scripts: []string{"512077aab6e066f8a7419c5ab714c12c67d25007ed55a43cadcacb4d7a970a093f11"},
+		},
+		{
+			desc:    "tr(L4rK1yDtCWekvXuE6oXD9jCYfFNV2cWRpVuPLBcCU2z8TrisoyY1)",
+			scripts: []string{"512077aab6e066f8a7419c5ab714c12c67d25007ed55a43cadcacb4d7a970a093f11"},
+		},
+		{
+			desc:    "tr(a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd,pk(669b8afcec803a0d323e9a17f3ea8e68e8abe5a278020a929adbec52421adbd0))",
+			scripts: []string{"512017cf18db381d836d8923b1bdb246cfcd818da1a9f0e6e7907f187f0b2f937754"},
+		},
+	} {
+		param := test.param
+		if param == nil {
+			param = BitcoinMain
+		}
+		d, err := ParseDescriptor(test.desc, param)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if d.IsRange() != (len(test.scripts) > 1) {
+			t.Errorf("#%d: IsRange is %v", i, d.IsRange())
+		}
+		for j, s := range test.scripts {
+			out, err := d.Expand(uint32(j))
+			if err != nil {
+				t.Fatalf("#%d: %v", i, err)
+			}
+			if h := hex.EncodeToString(out.ScriptPubKey); h != s {
+				t.Errorf("#%d-%d: script %s, expected %s", i, j, h, s)
+			}
+			if test.addr != "" && out.Address.String() != test.addr {
+				t.Errorf("#%d: address %s, expected %s", i, out.Address, test.addr)
+			}
+		}
+	}
+}
+
+//TestDescriptorRange tests ranged descriptors with the vectors of BIP86.
+func TestDescriptorRange(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	master, err := NewMaster(NewSeed(mnemonic, ""), BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc, err := master.DerivePath(DerivationPath{
+		86 + HardenedKeyStart, HardenedKeyStart, HardenedKeyStart,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	xpub, err := acc.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs := []string{
+		"bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+		"bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh",
+	}
+	for _, desc := range []string{
+		"tr(" + master.String() + "/86'/0'/0'/0/*)",
+		"tr([73c5da0a/86'/0'/0']" + xpub.String() + "/0/*)",
+	} {
+		d, err := ParseDescriptor(desc, BitcoinMain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !d.IsRange() {
+			t.Error("descriptor must be ranged")
+		}
+		for i, a := range addrs {
+			out, err := d.Expand(uint32(i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Address.String() != a {
+				t.Errorf("%d: address %s, expected %s", i, out.Address, a)
+			}
+		}
+		if _, err := d.Expand(HardenedKeyStart); err != ErrDescriptorIndex {
+			t.Errorf("hardened index must not be expanded: %v", err)
+		}
+		d2, err := ParseDescriptor(d.String(), BitcoinMain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d2.String() != d.String() {
+			t.Errorf("round trip %s, expected %s", d2, d)
+		}
+	}
+	if master.Fingerprint() != 0x73c5da0a {
+		t.Errorf("fingerprint %08x", master.Fingerprint())
+	}
+
+	_, err = ParseDescriptor("tr("+xpub.String()+"/0/*')", BitcoinMain)
+	if err != ErrDeriveHardFromPublic {
+		t.Errorf("hardened range from xpub must fail: %v", err)
+	}
+}
+
+//TestDescriptorString tests printing and invalid descriptors.
+func TestDescriptorString(t *testing.T) {
+	desc := "pkh([d34db33f/44'/0'/0']xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL/1/*)#ml40v0wf"
+	d, err := ParseDescriptor(desc, BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.String() != desc {
+		t.Errorf("descriptor %s, expected %s", d, desc)
+	}
+
+	k := "03a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd"
+	u := "04a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd5b8dec5235a0fa8722476c7709c02559e3aa73aa03918ba2d492eea75abea235"
+	for i, s := range []string{
+		"wpkh(" + u + ")",
+		"wsh(pk(" + u + "))",
+		"tr(" + u + ")",
+		"sh(sh(pk(" + k + ")))",
+		"wsh(wsh(pk(" + k + ")))",
+		"wsh(wpkh(" + k + "))",
+		"sh(tr(" + k + "))",
+		"sh(raw(deadbeef))",
+		"wsh(addr(1PMycacnJaSqwwJqjawXBErnLsZ7RkXUAs))",
+		"tr(" + k + ",pkh(" + k + "))",
+		"tr(" + k + ",{pk(" + k + ")})",
+		"multi(0," + k + ")",
+		"multi(2," + k + ")",
+		"pk(" + k + "",
+		"pk(" + k + "))",
+		"pk(" + k + "," + k + ")",
+		"pk([d34db33f/m/1]" + k + ")",
+		"pk([d34db3/1]" + k + ")",
+		"unknown(" + k + ")",
+		"a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd",
+		"pk(a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd)",
+	} {
+		if _, err := ParseDescriptor(s, BitcoinMain); !errors.Is(err, ErrInvalidDescriptor) {
+			t.Errorf("#%d: %s must be invalid: %v", i, s, err)
+		}
+	}
+}
@@ -29,13 +29,16 @@
 package address
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"errors"
 	"fmt"
 	"log"
 
 	"github.com/bitgoin/address/base58"
+	"github.com/bitgoin/address/bech32"
 	"github.com/bitgoin/address/btcec"
+	"github.com/bitgoin/address/cashaddr"
 	"golang.org/x/crypto/ripemd160"
 )
 
@@ -45,11 +48,21 @@ var (
 
 //Params is parameters of the coin.
 type Params struct {
+	//Name is the unique name of the network in the registry.
+	Name                   string
 	DumpedPrivateKeyHeader []byte
 	AddressHeader          []byte
 	P2SHHeader             []byte
 	HDPrivateKeyID         []byte
 	HDPublicKeyID          []byte
+	Bech32HRP              string
+	//CashAddrPrefix is the prefix of CashAddr addresses, which is empty if
+	//the network doesn't use CashAddr.
+	CashAddrPrefix string
+	//CoinType is the coin type registered in SLIP-44.
+	CoinType uint32
+	//MessageMagic is the prefix of messages signed by SignMessage.
+	MessageMagic string
 }
 
 //PublicKey represents public key for bitcoin
@@ -83,25 +96,40 @@ func NewPublicKey(pubKeyByte []byte, param *Params) (*PublicKey, error) {
 }
 
 //FromWIF gets PublicKey and PrivateKey from private key of WIF format.
-func FromWIF(wif string, param *Params) (*PrivateKey, error) {
+//The header byte of the WIF must match one of params, or one of the
+//registered networks if params is empty. If several networks share the
+//header, the first one wins.
+func FromWIF(wif string, params ...*Params) (*PrivateKey, error) {
+	if len(params) == 0 {
+		params = RegisteredParams()
+	}
 	pb, err := base58.Decode(wif)
 	if err != nil {
 		return nil, err
 	}
-	ok := false
-	for _, h := range param.DumpedPrivateKeyHeader {
-		if pb[0] == h {
-			ok = true
+	if len(pb) != btcec.PrivKeyBytesLen+1 && len(pb) != btcec.PrivKeyBytesLen+2 {
+		return nil, errors.New("wif is invalid")
+	}
+	var param *Params
+	for _, p := range params {
+		if bytes.IndexByte(p.DumpedPrivateKeyHeader, pb[0]) >= 0 {
+			param = p
+			break
 		}
 	}
-	if !ok {
+	if param == nil {
 		return nil, errors.New("wif is invalid")
 	}
 	isCompressed := false
-	if len(pb) == btcec.PrivKeyBytesLen+2 && pb[btcec.PrivKeyBytesLen+1] == 0x01 {
+	if len(pb) == btcec.PrivKeyBytesLen+2 {
+		if pb[btcec.PrivKeyBytesLen+1] != 0x01 {
+			return nil, errors.New("wif is invalid")
+		}
 		pb = pb[:len(pb)-1]
 		isCompressed = true
-		log.Println("compressed")
+	}
+	if !validPrivateKey(pb[1:]) {
+		return nil, errors.New("private key of wif is out of range")
 	}
 
 	//Get the raw public
@@ -197,7 +225,40 @@ func (pub *PublicKey) Address() string {
 	return base58.Encode(ripeHashedBytes)
 }
 
+//CashAddress returns P2PKH address in CashAddr format from PublicKey.
+//It returns ErrNoCashAddr if the network doesn't use CashAddr.
+func (pub *PublicKey) CashAddress() (string, error) {
+	return encodeCashAddr(pub.param, cashaddr.P2KH, pub.AddressBytes())
+}
+
+//WitnessAddress returns native segwit (P2WPKH) address from PublicKey.
+//The compressed public key is always used regardless of isCompressed
+//because uncompressed keys are not standard in segwit.
+func (pub *PublicKey) WitnessAddress() (string, error) {
+	return bech32.EncodeSegwit(pub.param.Bech32HRP, 0, pub.WitnessProgram())
+}
+
+//WitnessProgram returns the P2WPKH witness program, i.e. hash160 of the
+//compressed public key.
+func (pub *PublicKey) WitnessProgram() []byte {
+	return AddressBytes(pub.SerializeCompressed())
+}
+
+//NestedWitnessRedeemScript returns the redeem script 0x0014<hash160> of
+//nested segwit (P2SH-P2WPKH), which must be put in the scriptSig to spend
+//from NestedWitnessAddress.
+func (pub *PublicKey) NestedWitnessRedeemScript() []byte {
+	return append([]byte{0x00, 0x14}, pub.WitnessProgram()...)
+}
+
+//NestedWitnessAddress returns nested segwit (P2SH-P2WPKH) address from
+//PublicKey.
+func (pub *PublicKey) NestedWitnessAddress() string {
+	return ScriptAddress(pub.NestedWitnessRedeemScript(), pub.param.P2SHHeader[0])
+}
+
 //DecodeAddress converts bitcoin address to hex form.
+//Use ParseAddress to know the type and the network of the address.
 func DecodeAddress(addr string) ([]byte, error) {
 	pb, err := base58.Decode(addr)
 	if err != nil {
@@ -229,8 +290,11 @@ func AddressBytes(redeem []byte) []byte {
 	return ripeHash.Sum(nil)
 }
 
-//Address returns ripeme160(sha256(redeem)) (address of redeem script).
-func Address(redeem []byte, header byte) string {
+//ScriptAddress returns base58 encoded ripeme160(sha256(redeem)) (P2SH
+//address of redeem script).
+//It was named Address before Address became the interface of all address
+//types; callers of Address(redeem, header) should call it instead.
+func ScriptAddress(redeem []byte, header byte) string {
 	ripeHashedBytes := AddressBytes(redeem)
 	ripeHashedBytes = append(ripeHashedBytes, 0x0)
 	copy(ripeHashedBytes[1:], ripeHashedBytes[:len(ripeHashedBytes)-1])
@@ -238,3 +302,10 @@ func Address(redeem []byte, header byte) string {
 
 	return base58.Encode(ripeHashedBytes)
 }
+
+//WitnessScriptAddress returns native segwit (P2WSH) address of the witness
+//script, i.e. bech32 encoded sha256(script).
+func WitnessScriptAddress(script []byte, hrp string) (string, error) {
+	h := sha256.Sum256(script)
+	return bech32.EncodeSegwit(hrp, 0, h[:])
+}
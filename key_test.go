@@ -33,6 +33,7 @@ import (
 	"log"
 	"testing"
 
+	"github.com/bitgoin/address/base58"
 	"github.com/bitgoin/address/btcec"
 )
 
@@ -81,6 +82,46 @@ func TestKeys(t *testing.T) {
 
 }
 
+func TestFromWIFInvalid(t *testing.T) {
+	n := btcec.S256().N.Bytes()
+	nm1 := btcec.S256().N.Bytes()
+	nm1[31]--
+	one := make([]byte, 32)
+	one[31] = 1
+	tests := []struct {
+		key   []byte
+		flag  []byte
+		valid bool
+	}{
+		{one, nil, true},
+		{one, []byte{0x01}, true},
+		{nm1, []byte{0x01}, true},
+		{one, []byte{0x00}, false},
+		{one, []byte{0x02}, false},
+		{make([]byte, 32), nil, false},
+		{make([]byte, 32), []byte{0x01}, false},
+		{n, nil, false},
+		{n, []byte{0x01}, false},
+	}
+	for i, test := range tests {
+		b := append([]byte{0x80}, test.key...)
+		wif := base58.Encode(append(b, test.flag...))
+		key, err := FromWIF(wif, BitcoinMain)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("#%d: %s should be invalid", i, wif)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if key.WIFAddress() != wif {
+			t.Errorf("#%d: got %s, expected %s", i, key.WIFAddress(), wif)
+		}
+	}
+}
+
 func TestSign(t *testing.T) {
 	seed := make([]byte, 32)
 	_, err := hex.Decode(seed, []byte("3954e0c9a3ce58a8dca793e214232e569ff0cb9da79689ca56d0af614227d540"))
@@ -111,3 +152,59 @@ func TestSign(t *testing.T) {
 	}
 	log.Println(err)
 }
+
+func TestWitnessAddress(t *testing.T) {
+	pb, err := hex.DecodeString("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := NewPublicKey(pb, BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adr, err := pub.WitnessAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adr != "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4" {
+		t.Error("invalid p2wpkh address", adr)
+	}
+
+	script := append([]byte{0x21}, pb...)
+	script = append(script, 0xac)
+	adr, err = WitnessScriptAddress(script, BitcoinMain.Bech32HRP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adr != "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3" {
+		t.Error("invalid p2wsh address", adr)
+	}
+	adr, err = WitnessScriptAddress(script, BitcoinTest.Bech32HRP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adr != "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7" {
+		t.Error("invalid p2wsh address", adr)
+	}
+}
+
+//TestNestedWitnessAddress tests with the vector of BIP49.
+func TestNestedWitnessAddress(t *testing.T) {
+	pb, err := hex.DecodeString("03a1af804ac108a8a51782198c2d034b28bf90c8803f5a53f76276fa69a4eae77f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := NewPublicKey(pb, BitcoinTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := hex.EncodeToString(pub.WitnessProgram()); p != "38971f73930f6c141d977ac4fd4a727c854935b3" {
+		t.Error("invalid witness program", p)
+	}
+	if r := hex.EncodeToString(pub.NestedWitnessRedeemScript()); r != "001438971f73930f6c141d977ac4fd4a727c854935b3" {
+		t.Error("invalid redeem script", r)
+	}
+	if adr := pub.NestedWitnessAddress(); adr != "2Mww8dCYPUpKHofjgcXcBCEGmniw9CoaiD2" {
+		t.Error("invalid p2sh-p2wpkh address", adr)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+
+	"github.com/bitgoin/address/btcec"
+)
+
+var (
+	//ErrInvalidMessageSignature is returned when the signature of the message
+	//is malformed or is not signed by the key of the address.
+	ErrInvalidMessageSignature = errors.New("invalid message signature")
+
+	//ErrNotP2PKHAddress is returned when the address to verify the message
+	//is not a P2PKH address.
+	ErrNotP2PKHAddress = errors.New("message can be verified only with P2PKH address")
+)
+
+//messageHash returns the double sha256 of msg prefixed by the message magic
+//of param.
+func messageHash(msg string, param *Params) []byte {
+	var b bytes.Buffer
+	writeVarBytes(&b, []byte(param.MessageMagic))
+	writeVarBytes(&b, []byte(msg))
+	return doubleSHA256(b.Bytes())
+}
+
+//SignMessage signs msg and returns base64 encoded compact signature,
+//which is compatible with signmessage of bitcoind.
+func (priv *PrivateKey) SignMessage(msg string) (string, error) {
+	pub := priv.PublicKey
+	sig, err := btcec.SignCompact(secp256k1, priv.PrivateKey,
+		messageHash(msg, pub.param), pub.isCompressed)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+//VerifyMessage verifies base64 encoded compact signature of msg is signed
+//by the key of P2PKH address, like verifymessage of bitcoind.
+func VerifyMessage(address, signature, msg string, param *Params) error {
+	adr, err := ParseAddress(address, param)
+	if err != nil {
+		return err
+	}
+	p2pkh, ok := adr.(*P2PKHAddress)
+	if !ok {
+		return ErrNotP2PKHAddress
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return err
+	}
+	//header byte is 27-30 for uncompressed and 31-34 for compressed keys.
+	if len(sig) == 0 || sig[0] < 27 || sig[0] > 34 {
+		return ErrInvalidMessageSignature
+	}
+	key, isCompressed, err := btcec.RecoverCompact(secp256k1, sig,
+		messageHash(msg, param))
+	if err != nil {
+		return ErrInvalidMessageSignature
+	}
+	pub := PublicKey{
+		PublicKey:    key,
+		isCompressed: isCompressed,
+		param:        param,
+	}
+	if !bytes.Equal(pub.AddressBytes(), p2pkh.Hash()) {
+		return ErrInvalidMessageSignature
+	}
+	return nil
+}
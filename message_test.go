@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import "testing"
+
+func TestSignMessage(t *testing.T) {
+	key, err := FromWIF("L4rK1yDtCWekvXuE6oXD9jCYfFNV2cWRpVuPLBcCU2z8TrisoyY1", BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adr := key.PublicKey.Address()
+	if adr != "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV" {
+		t.Fatal("invalid address", adr)
+	}
+	msg := "This is an example of a signed message."
+	sig, err := key.SignMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig != "H9L5yLFjti0QTHhPyFrZCT1V/MMnBtXKmoiKDZ78NDBjERki6ZTQZdSMCtkgoNmp17By9ItJr8o7ChX0XxY91nk=" {
+		t.Error("invalid signature", sig)
+	}
+	if err := VerifyMessage(adr, sig, msg, BitcoinMain); err != nil {
+		t.Error(err)
+	}
+	if err := VerifyMessage(adr, sig, msg+" ", BitcoinMain); err != ErrInvalidMessageSignature {
+		t.Error("signature of another message should be invalid", err)
+	}
+	if err := VerifyMessage("1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", sig, msg, BitcoinMain); err != ErrInvalidMessageSignature {
+		t.Error("signature for another address should be invalid", err)
+	}
+	if err := VerifyMessage("3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", sig, msg, BitcoinMain); err != ErrNotP2PKHAddress {
+		t.Error("P2SH address should not be accepted", err)
+	}
+}
+
+func TestSignMessageUncompressed(t *testing.T) {
+	key, err := Generate(MonacoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.PublicKey.isCompressed = false
+	msg := "monacoin"
+	sig, err := key.SignMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adr := key.PublicKey.Address()
+	if err := VerifyMessage(adr, sig, msg, MonacoinMain); err != nil {
+		t.Error(err)
+	}
+	//the signature is for the uncompressed key.
+	key.PublicKey.isCompressed = true
+	if err := VerifyMessage(key.PublicKey.Address(), sig, msg, MonacoinMain); err != ErrInvalidMessageSignature {
+		t.Error("compressed flag should be honoured", err)
+	}
+	//the message magic differs.
+	if err := VerifyMessage(adr, sig, msg, BitcoinMain); err == nil {
+		t.Error("signature for monacoin should not be valid for bitcoin")
+	}
+}
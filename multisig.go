@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"sort"
+
+	"github.com/bitgoin/address/btcec"
+)
+
+//opcodes of multisig scripts.
+const (
+	op1             = 0x51
+	op16            = 0x60
+	opCheckMultiSig = 0xae
+)
+
+//MaxMultisigKeys is the maximum number of public keys in a standard
+//multisig script.
+const MaxMultisigKeys = 16
+
+var (
+	//ErrInvalidMultisigParams is returned when m or the number of public keys
+	//is out of the range.
+	ErrInvalidMultisigParams = errors.New("multisig requires 1 <= m <= n <= 16")
+
+	//ErrUncompressedSortedKey is returned when an uncompressed public key is
+	//used in a BIP67 sorted multisig.
+	ErrUncompressedSortedKey = errors.New("BIP67 requires compressed public keys")
+
+	//ErrNotMultisigScript is returned when the script is not a standard
+	//multisig script.
+	ErrNotMultisigScript = errors.New("not a multisig script")
+)
+
+//NewMultisigScript returns the m-of-n multisig script
+//OP_m <pubkey>... OP_n OP_CHECKMULTISIG of pubs.
+//If sorted is true, public keys are sorted in the lexicographic order of
+//their serialization as specified in BIP67, so that the script doesn't
+//depend on the order of pubs.
+func NewMultisigScript(m int, pubs []*PublicKey, sorted bool) ([]byte, error) {
+	if m < 1 || m > len(pubs) || len(pubs) > MaxMultisigKeys {
+		return nil, ErrInvalidMultisigParams
+	}
+	keys := make([][]byte, len(pubs))
+	for i, pub := range pubs {
+		keys[i] = pub.Serialize()
+		if sorted && !pub.isCompressed {
+			return nil, ErrUncompressedSortedKey
+		}
+	}
+	if sorted {
+		sort.Slice(keys, func(i, j int) bool {
+			return bytes.Compare(keys[i], keys[j]) < 0
+		})
+	}
+	script := []byte{byte(op1 + m - 1)}
+	for _, k := range keys {
+		script = append(script, byte(len(k)))
+		script = append(script, k...)
+	}
+	return append(script, byte(op1+len(pubs)-1), opCheckMultiSig), nil
+}
+
+//ParseMultisigScript returns the number of required signatures m and the
+//public keys of the multisig script. The number of keys n is len(pubs).
+func ParseMultisigScript(script []byte, param *Params) (int, []*PublicKey, error) {
+	l := len(script)
+	if l < 3 || script[l-1] != opCheckMultiSig ||
+		script[0] < op1 || script[0] > op16 ||
+		script[l-2] < op1 || script[l-2] > op16 {
+		return 0, nil, ErrNotMultisigScript
+	}
+	m := int(script[0]-op1) + 1
+	n := int(script[l-2]-op1) + 1
+	var pubs []*PublicKey
+	for i := 1; i < l-2; {
+		kl := int(script[i])
+		if (kl != btcec.PubKeyBytesLenCompressed &&
+			kl != btcec.PubKeyBytesLenUncompressed) || i+1+kl > l-2 {
+			return 0, nil, ErrNotMultisigScript
+		}
+		pub, err := NewPublicKey(script[i+1:i+1+kl], param)
+		if err != nil {
+			return 0, nil, err
+		}
+		pubs = append(pubs, pub)
+		i += 1 + kl
+	}
+	if len(pubs) != n || m > n {
+		return 0, nil, ErrNotMultisigScript
+	}
+	return m, pubs, nil
+}
+
+//ScriptP2SHAddress returns the P2SH address of the redeem script.
+func ScriptP2SHAddress(script []byte, param *Params) (*P2SHAddress, error) {
+	return NewP2SHAddress(AddressBytes(script), param)
+}
+
+//ScriptP2WSHAddress returns the native segwit P2WSH address of the witness
+//script.
+func ScriptP2WSHAddress(script []byte, param *Params) (*P2WSHAddress, error) {
+	h := sha256.Sum256(script)
+	return NewP2WSHAddress(h[:], param)
+}
+
+//ScriptP2SHP2WSHAddress returns the nested segwit P2SH-P2WSH address of the
+//witness script. Its redeem script is the ScriptPubKey of the P2WSH address.
+func ScriptP2SHP2WSHAddress(script []byte, param *Params) (*P2SHAddress, error) {
+	w, err := ScriptP2WSHAddress(script, param)
+	if err != nil {
+		return nil, err
+	}
+	return ScriptP2SHAddress(w.ScriptPubKey(), param)
+}
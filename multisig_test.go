@@ -0,0 +1,209 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+//TestMultisigBIP67 tests with the vectors of BIP67.
+func TestMultisigBIP67(t *testing.T) {
+	for i, test := range []struct {
+		pubs   []string
+		m      int
+		sorted []string
+		addr   string
+	}{
+		{
+			pubs: []string{
+				"02ff12471208c14bd580709cb2358d98975247d8765f92bc25eab3b2763ed605f8",
+				"02fe6f0a5a297eb38c391581c4413e084773ea23954d93f7753db7dc0adc188b2f",
+			},
+			m: 2,
+			sorted: []string{
+				"02fe6f0a5a297eb38c391581c4413e084773ea23954d93f7753db7dc0adc188b2f",
+				"02ff12471208c14bd580709cb2358d98975247d8765f92bc25eab3b2763ed605f8",
+			},
+			addr: "39bgKC7RFbpoCRbtD5KEdkYKtNyhpsNa3Z",
+		},
+		{
+			pubs: []string{
+				"02632b12f4ac5b1d1b72b2a3b508c19172de44f6f46bcee50ba33f3f9291e47ed0",
+				"027735a29bae7780a9755fae7a1c4374c656ac6a69ea9f3697fda61bb99a4f3e77",
+				"02e2cc6bd5f45edd43bebe7cb9b675f0ce9ed3efe613b177588290ad188d11b404",
+			},
+			m: 2,
+			sorted: []string{
+				"02632b12f4ac5b1d1b72b2a3b508c19172de44f6f46bcee50ba33f3f9291e47ed0",
+				"027735a29bae7780a9755fae7a1c4374c656ac6a69ea9f3697fda61bb99a4f3e77",
+				"02e2cc6bd5f45edd43bebe7cb9b675f0ce9ed3efe613b177588290ad188d11b404",
+			},
+			addr: "3CKHTjBKxCARLzwABMu9yD85kvtm7WnMfH",
+		},
+		{
+			pubs: []string{
+				"030000000000000000000000000000000000004141414141414141414141414141",
+				"020000000000000000000000000000000000004141414141414141414141414141",
+				"020000000000000000000000000000000000004141414141414141414141414140",
+				"030000000000000000000000000000000000004141414141414141414141414140",
+			},
+			m: 2,
+			sorted: []string{
+				"020000000000000000000000000000000000004141414141414141414141414140",
+				"020000000000000000000000000000000000004141414141414141414141414141",
+				"030000000000000000000000000000000000004141414141414141414141414140",
+				"030000000000000000000000000000000000004141414141414141414141414141",
+			},
+			addr: "32V85igBri9zcfBRVupVvwK18NFtS37FuD",
+		},
+		{
+			pubs: []string{
+				"022df8750480ad5b26950b25c7ba79d3e37d75f640f8e5d9bcd5b150a0f85014da",
+				"03e3818b65bcc73a7d64064106a859cc1a5a728c4345ff0b641209fba0d90de6e9",
+				"021f2f6e1e50cb6a953935c3601284925decd3fd21bc445712576873fb8c6ebc18",
+			},
+			m: 2,
+			sorted: []string{
+				"021f2f6e1e50cb6a953935c3601284925decd3fd21bc445712576873fb8c6ebc18",
+				"022df8750480ad5b26950b25c7ba79d3e37d75f640f8e5d9bcd5b150a0f85014da",
+				"03e3818b65bcc73a7d64064106a859cc1a5a728c4345ff0b641209fba0d90de6e9",
+			},
+			addr: "3Q4sF6tv9wsdqu2NtARzNCpQgwifm2rAba",
+		},
+	} {
+		var pubs []*PublicKey
+		for _, p := range test.pubs {
+			pub, err := NewPublicKey(mustDecodeHex(t, p), BitcoinMain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pubs = append(pubs, pub)
+		}
+		script, err := NewMultisigScript(test.m, pubs, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, parsed, err := ParseMultisigScript(script, BitcoinMain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != test.m || len(parsed) != len(test.sorted) {
+			t.Fatalf("#%d: parsed %d-of-%d", i, m, len(parsed))
+		}
+		for j, p := range parsed {
+			if s := hex.EncodeToString(p.Serialize()); s != test.sorted[j] {
+				t.Errorf("#%d: key %d is %s, expected %s", i, j, s, test.sorted[j])
+			}
+		}
+		adr, err := ScriptP2SHAddress(script, BitcoinMain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if adr.String() != test.addr {
+			t.Errorf("#%d: address %s, expected %s", i, adr, test.addr)
+		}
+
+		unsorted, err := NewMultisigScript(test.m, pubs, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, parsed, err = ParseMultisigScript(unsorted, BitcoinMain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j, p := range parsed {
+			if s := hex.EncodeToString(p.Serialize()); s != test.pubs[j] {
+				t.Errorf("#%d: unsorted key %d is %s, expected %s", i, j, s, test.pubs[j])
+			}
+		}
+	}
+}
+
+//TestMultisigAddresses tests address helpers and invalid parameters.
+func TestMultisigAddresses(t *testing.T) {
+	pub, err := NewPublicKey(mustDecodeHex(t, "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"), BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := NewMultisigScript(1, []*PublicKey{pub}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := hex.EncodeToString(script); h != "51210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f8179851ae" {
+		t.Errorf("invalid script %s", h)
+	}
+	w, err := ScriptP2WSHAddress(script, BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := WitnessScriptAddress(script, BitcoinMain.Bech32HRP); w.String() != a {
+		t.Errorf("P2WSH address %s, expected %s", w, a)
+	}
+	n, err := ScriptP2SHP2WSHAddress(script, BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := ScriptAddress(w.ScriptPubKey(), BitcoinMain.P2SHHeader[0]); n.String() != a {
+		t.Errorf("P2SH-P2WSH address %s, expected %s", n, a)
+	}
+
+	for i, test := range []struct {
+		m    int
+		n    int
+		fail error
+	}{
+		{0, 1, ErrInvalidMultisigParams},
+		{2, 1, ErrInvalidMultisigParams},
+		{1, 17, ErrInvalidMultisigParams},
+		{16, 16, nil},
+	} {
+		pubs := make([]*PublicKey, test.n)
+		for j := range pubs {
+			pubs[j] = pub
+		}
+		if _, err := NewMultisigScript(test.m, pubs, false); err != test.fail {
+			t.Errorf("#%d: error %v, expected %v", i, err, test.fail)
+		}
+	}
+
+	for i, s := range []string{
+		"",
+		"51ae",
+		"5121020000000000000000000000000000000000000000000000000000000000000000151ae",
+		"52210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f8179851ae",
+		"51210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f8179852ae",
+		"51210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f8179851ac",
+	} {
+		b, _ := hex.DecodeString(s)
+		if _, _, err := ParseMultisigScript(b, BitcoinMain); err == nil {
+			t.Errorf("#%d: invalid multisig script must not be parsed", i)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+)
+
+var (
+	//ErrUnknownParams is returned when no Params is registered with the
+	//name.
+	ErrUnknownParams = errors.New("unknown network")
+
+	//ErrDuplicateParams is returned when Params with the same name is
+	//already registered.
+	ErrDuplicateParams = errors.New("network is already registered")
+
+	//ErrInvalidParams is returned when Params to be registered is nil or
+	//has no name.
+	ErrInvalidParams = errors.New("invalid network")
+)
+
+//paramsMu guards registeredParams.
+var paramsMu sync.RWMutex
+
+//registeredParams is the registry of networks. When networks share a
+//prefix, the one registered first wins in auto-detection.
+var registeredParams = []*Params{
+	BitcoinMain, BitcoinTest, MonacoinMain,
+	BitcoinSignet, BitcoinRegtest, MonacoinTest,
+	LitecoinMain, LitecoinTest, DogecoinMain, DogecoinTest,
+	BitcoinCashMain, BitcoinCashTest,
+}
+
+//RegisterParams adds param to the registry so that it can be looked up and
+//auto-detected by FromWIF, ParseAddress and NewKeyFromString.
+func RegisterParams(param *Params) error {
+	if param == nil || param.Name == "" {
+		return ErrInvalidParams
+	}
+	paramsMu.Lock()
+	defer paramsMu.Unlock()
+	for _, p := range registeredParams {
+		if p.Name == param.Name {
+			return ErrDuplicateParams
+		}
+	}
+	registeredParams = append(registeredParams, param)
+	return nil
+}
+
+//RegisteredParams returns all registered networks in the order of the
+//registration.
+func RegisteredParams() []*Params {
+	paramsMu.RLock()
+	defer paramsMu.RUnlock()
+	return append([]*Params{}, registeredParams...)
+}
+
+//LookupParams returns the registered network with the name.
+func LookupParams(name string) (*Params, error) {
+	for _, p := range RegisteredParams() {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, ErrUnknownParams
+}
+
+//ParamsByWIFHeader returns the registered networks whose WIF private keys
+//start with the header byte h.
+func ParamsByWIFHeader(h byte) []*Params {
+	var ps []*Params
+	for _, p := range RegisteredParams() {
+		if bytes.IndexByte(p.DumpedPrivateKeyHeader, h) >= 0 {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+//ParamsByAddressHeader returns the registered networks whose P2PKH or P2SH
+//addresses start with the header byte h.
+func ParamsByAddressHeader(h byte) []*Params {
+	var ps []*Params
+	for _, p := range RegisteredParams() {
+		if bytes.Equal(p.AddressHeader, []byte{h}) ||
+			bytes.Equal(p.P2SHHeader, []byte{h}) {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+//ParamsByHRP returns the registered networks whose bech32 human readable
+//part is hrp, which is case-insensitive.
+func ParamsByHRP(hrp string) []*Params {
+	var ps []*Params
+	for _, p := range RegisteredParams() {
+		if p.Bech32HRP != "" && strings.EqualFold(p.Bech32HRP, hrp) {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+//ParamsByHDVersion returns the registered networks which accept the version
+//bytes id of extended keys, including versions registered by
+//RegisterHDVersion.
+func ParamsByHDVersion(id []byte) []*Params {
+	var ps []*Params
+	for _, p := range RegisteredParams() {
+		if _, _, err := findHDVersion(id, p); err == nil {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
@@ -0,0 +1,317 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestParamsRegistry(t *testing.T) {
+	for _, p := range RegisteredParams() {
+		q, err := LookupParams(p.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q != p {
+			t.Error("invalid params for", p.Name)
+		}
+	}
+	if _, err := LookupParams("unknown"); err != ErrUnknownParams {
+		t.Error("unknown name must not be found", err)
+	}
+	if err := RegisterParams(&Params{Name: BitcoinMain.Name}); err != ErrDuplicateParams {
+		t.Error("duplicate name must not be registered", err)
+	}
+
+	for i, test := range []struct {
+		ps   []*Params
+		want []*Params
+	}{
+		{ParamsByWIFHeader(128), []*Params{BitcoinMain, BitcoinCashMain}},
+		{ParamsByWIFHeader(176), []*Params{MonacoinMain, LitecoinMain}},
+		{ParamsByWIFHeader(1), nil},
+		{ParamsByAddressHeader(0), []*Params{BitcoinMain, BitcoinCashMain}},
+		{ParamsByAddressHeader(5), []*Params{BitcoinMain, MonacoinMain, BitcoinCashMain}},
+		{ParamsByAddressHeader(196), []*Params{BitcoinTest, BitcoinSignet, BitcoinRegtest, DogecoinTest, BitcoinCashTest}},
+		{ParamsByHRP("TB"), []*Params{BitcoinTest, BitcoinSignet}},
+		{ParamsByHRP("mona"), []*Params{MonacoinMain}},
+		{ParamsByHRP(""), nil},
+		{ParamsByHDVersion([]byte{0x04, 0x88, 0xb2, 0x1e}), []*Params{BitcoinMain, MonacoinMain, BitcoinCashMain}},
+		{ParamsByHDVersion([]byte{0x04, 0x5f, 0x1c, 0xf6}), []*Params{BitcoinTest}},
+		{ParamsByHDVersion([]byte{0, 0, 0, 0}), nil},
+	} {
+		if len(test.ps) != len(test.want) {
+			t.Fatalf("#%d: %d networks, expected %d", i, len(test.ps), len(test.want))
+		}
+		for j := range test.ps {
+			if test.ps[j] != test.want[j] {
+				t.Errorf("#%d: %s, expected %s", i, test.ps[j].Name, test.want[j].Name)
+			}
+		}
+	}
+
+	n := len(registeredParams)
+	defer func() {
+		registeredParams = registeredParams[:n]
+	}()
+	p := &Params{
+		Name:                   "test",
+		DumpedPrivateKeyHeader: []byte{1},
+		AddressHeader:          []byte{2},
+		P2SHHeader:             []byte{3},
+		Bech32HRP:              "test",
+	}
+	if err := RegisterParams(p); err != nil {
+		t.Fatal(err)
+	}
+	if q, err := LookupParams("test"); err != nil || q != p {
+		t.Error("registered params is not found", err)
+	}
+	if ps := ParamsByAddressHeader(3); len(ps) != 1 || ps[0] != p {
+		t.Error("registered params is not found by address header")
+	}
+	if err := RegisterParams(&Params{Name: "test"}); err != ErrDuplicateParams {
+		t.Error("duplicated params must not be registered", err)
+	}
+	for _, q := range []*Params{nil, {}} {
+		if err := RegisterParams(q); err != ErrInvalidParams {
+			t.Error("invalid params must not be registered", err)
+		}
+	}
+}
+
+func TestParamsDetection(t *testing.T) {
+	pb, err := hex.DecodeString("0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []*Params{BitcoinMain, BitcoinTest, MonacoinMain} {
+		priv := NewPrivateKey(pb, p)
+		wif := priv.WIFAddress()
+		priv2, err := FromWIF(wif)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priv2.PublicKey.param != p {
+			t.Error("invalid network of wif", wif)
+		}
+		if priv2.WIFAddress() != wif {
+			t.Error("invalid wif", priv2.WIFAddress())
+		}
+		adr, err := ParseAddress(priv.PublicKey.Address())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if adr.Params() != p {
+			t.Error("invalid network of address", adr)
+		}
+	}
+	if _, err := FromWIF("5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ", BitcoinTest); err == nil {
+		t.Error("wif of mainnet must not be parsed for testnet")
+	}
+
+	master, err := NewMaster(make([]byte, RecommendedSeedLen), BitcoinTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{master.String(), mustNeuter(t, master).String()} {
+		k, err := NewKeyFromString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.param != BitcoinTest {
+			t.Error("invalid network of extended key", s)
+		}
+	}
+	k, err := NewKeyFromString("xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.param != BitcoinMain {
+		t.Error("xpub must be detected as the first registered network")
+	}
+	if _, err := NewKeyFromString(master.String(), BitcoinMain); err != ErrUnknownHDVersion {
+		t.Error("tprv must not be parsed for mainnet", err)
+	}
+}
+
+func mustNeuter(t *testing.T, k *ExtendedKey) *ExtendedKey {
+	n, err := k.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+//TestBuiltinParams tests addresses and WIF of the private key 1 on each
+//network.
+func TestBuiltinParams(t *testing.T) {
+	pb := make([]byte, 32)
+	pb[31] = 1
+	for _, test := range []struct {
+		param   *Params
+		p2pkh   string
+		p2sh    string
+		p2wpkh  string
+		wif     string
+		hdMagic string
+	}{
+		{
+			BitcoinMain,
+			"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH",
+			"3CNHUhP3uyB9EUtRLsmvFUmvGdjGdkTxJw",
+			"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			"KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn",
+			"xprv",
+		},
+		{
+			BitcoinTest,
+			"mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r",
+			"2N3vVYSK5XRgVSGWy21PnsRmBUywSQNdCsf",
+			"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+			"cMahea7zqjxrtgAbB7LSGbcQUr1uX1ojuat9jZodMN87JcbXMTcA",
+			"tprv",
+		},
+		{
+			BitcoinSignet,
+			"mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r",
+			"2N3vVYSK5XRgVSGWy21PnsRmBUywSQNdCsf",
+			"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+			"cMahea7zqjxrtgAbB7LSGbcQUr1uX1ojuat9jZodMN87JcbXMTcA",
+			"tprv",
+		},
+		{
+			BitcoinRegtest,
+			"mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r",
+			"2N3vVYSK5XRgVSGWy21PnsRmBUywSQNdCsf",
+			"bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080",
+			"cMahea7zqjxrtgAbB7LSGbcQUr1uX1ojuat9jZodMN87JcbXMTcA",
+			"tprv",
+		},
+		{
+			MonacoinTest,
+			"mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r",
+			"pGDqkrmKSB5Gq2W85s5cZXG2m8avFNfHfM",
+			"tmona1qw508d6qejxtdg4y5r3zarvary0c5xw7ks0dvvw",
+			"cMahea7zqjxrtgAbB7LSGbcQUr1uX1ojuat9jZodMN87JcbXMTcA",
+			"tprv",
+		},
+		{
+			LitecoinMain,
+			"LVuDpNCSSj6pQ7t9Pv6d6sUkLKoqDEVUnJ",
+			"MJaRnao1s62a2zAKSkmG582KbLKianqb7v",
+			"ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9",
+			"T33ydQRKp4FCW5LCLLUB7deioUMoveiwekdwUwyfRDeGZm76aUjV",
+			"Ltpv",
+		},
+		{
+			LitecoinTest,
+			"mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r",
+			"QXHFfTBKYXjaaTH1e7Rox8CcdNPGHVhM59",
+			"tltc1qw508d6qejxtdg4y5r3zarvary0c5xw7klfsuq0",
+			"cMahea7zqjxrtgAbB7LSGbcQUr1uX1ojuat9jZodMN87JcbXMTcA",
+			"tprv",
+		},
+		{
+			DogecoinMain,
+			"DFpN6QqFfUm3gKNaxN6tNcab1FArL9cZLE",
+			"A37YDYSwz3438rFtm1SLVcQHyD7JeueC9H",
+			"",
+			"QNcdLVw8fHkixm6NNyN6nVwxKek4u7qrioRbQmjxac5TVoTtZuot",
+			"dgpv",
+		},
+		{
+			DogecoinTest,
+			"nesRpRaAbTDmZHwmzBkLd2AtF7Z9L9z5S2",
+			"2N3vVYSK5XRgVSGWy21PnsRmBUywSQNdCsf",
+			"",
+			"cejxntqoC3o8qiC8HG8DrwoNyiRDBrMCEU8QrUVpLKdXsGy8LpTM",
+			"tprv",
+		},
+	} {
+		name := test.param.Name
+		priv := NewPrivateKey(pb, test.param)
+		if wif := priv.WIFAddress(); wif != test.wif {
+			t.Error(name, "invalid wif", wif)
+		}
+		priv2, err := FromWIF(test.wif, test.param)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if priv2.D.Cmp(priv.D) != 0 {
+			t.Error(name, "invalid private key from wif")
+		}
+		pub := priv.PublicKey
+		if adr := pub.Address(); adr != test.p2pkh {
+			t.Error(name, "invalid p2pkh address", adr)
+		}
+		//P2SH address whose script hash is the same as the key hash.
+		p2sh, err := NewP2SHAddress(pub.AddressBytes(), test.param)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if p2sh.String() != test.p2sh {
+			t.Error(name, "invalid p2sh address", p2sh)
+		}
+		adr, err := pub.WitnessAddress()
+		switch {
+		case test.p2wpkh == "":
+			if test.param.Bech32HRP != "" {
+				t.Error(name, "must not have bech32 hrp")
+			}
+		case err != nil:
+			t.Fatal(name, err)
+		case adr != test.p2wpkh:
+			t.Error(name, "invalid p2wpkh address", adr)
+		}
+		for _, s := range []string{test.p2pkh, test.p2sh, test.p2wpkh} {
+			if s == "" {
+				continue
+			}
+			a, err := ParseAddress(s, test.param)
+			if err != nil {
+				t.Fatal(name, err)
+			}
+			if a.String() != s || a.Params() != test.param {
+				t.Error(name, "invalid parsed address", a)
+			}
+		}
+		master, err := NewMaster(make([]byte, RecommendedSeedLen), test.param)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if s := master.String(); s[:4] != test.hdMagic {
+			t.Error(name, "invalid extended key", s)
+		}
+		if _, err := NewKeyFromString(master.String(), test.param); err != nil {
+			t.Error(name, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package psbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+//maxItems is the maximum number of inputs, outputs and witness items to be
+//read, to avoid huge allocations.
+const maxItems = 1 << 16
+
+func writeVarInt(w *bytes.Buffer, v uint64) {
+	var b [9]byte
+	switch {
+	case v < 0xfd:
+		w.WriteByte(byte(v))
+	case v <= 0xffff:
+		b[0] = 0xfd
+		binary.LittleEndian.PutUint16(b[1:], uint16(v))
+		w.Write(b[:3])
+	case v <= 0xffffffff:
+		b[0] = 0xfe
+		binary.LittleEndian.PutUint32(b[1:], uint32(v))
+		w.Write(b[:5])
+	default:
+		b[0] = 0xff
+		binary.LittleEndian.PutUint64(b[1:], v)
+		w.Write(b[:])
+	}
+}
+
+//readVarInt reads a compact size uint, which must be encoded canonically.
+func readVarInt(r *bytes.Reader) (uint64, error) {
+	d, err := r.ReadByte()
+	if err != nil {
+		return 0, ErrInvalidPsbtFormat
+	}
+	var b [8]byte
+	var v, min uint64
+	switch d {
+	case 0xfd:
+		_, err = io.ReadFull(r, b[:2])
+		v, min = uint64(binary.LittleEndian.Uint16(b[:])), 0xfd
+	case 0xfe:
+		_, err = io.ReadFull(r, b[:4])
+		v, min = uint64(binary.LittleEndian.Uint32(b[:])), 0x10000
+	case 0xff:
+		_, err = io.ReadFull(r, b[:])
+		v, min = binary.LittleEndian.Uint64(b[:]), 0x100000000
+	default:
+		return uint64(d), nil
+	}
+	if err != nil || v < min {
+		return 0, ErrInvalidPsbtFormat
+	}
+	return v, nil
+}
+
+func writeVarBytes(w *bytes.Buffer, b []byte) {
+	writeVarInt(w, uint64(len(b)))
+	w.Write(b)
+}
+
+func readVarBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, ErrInvalidPsbtFormat
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, ErrInvalidPsbtFormat
+	}
+	return b, nil
+}
+
+func readWitness(r *bytes.Reader) ([][]byte, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxItems {
+		return nil, ErrInvalidPsbtFormat
+	}
+	var wit [][]byte
+	for i := uint64(0); i < n; i++ {
+		item, err := readVarBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		wit = append(wit, item)
+	}
+	return wit, nil
+}
+
+func writeWitness(w *bytes.Buffer, wit [][]byte) {
+	writeVarInt(w, uint64(len(wit)))
+	for _, item := range wit {
+		writeVarBytes(w, item)
+	}
+}
@@ -0,0 +1,213 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package psbt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/bitgoin/address"
+)
+
+//errors of finalizing.
+var (
+	ErrNotFinalizable = errors.New("input is not fully signed or its script is not supported")
+	ErrNotFinalized   = errors.New("input is not finalized")
+)
+
+//Finalize finalizes all inputs. It returns ErrNotFinalizable if any input
+//is not fully signed.
+func (p *Packet) Finalize() error {
+	for i := range p.Inputs {
+		if err := p.FinalizeInput(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//IsComplete returns true if all inputs are finalized.
+func (p *Packet) IsComplete() bool {
+	for _, in := range p.Inputs {
+		if in.FinalScriptSig == nil && in.FinalScriptWitness == nil {
+			return false
+		}
+	}
+	return true
+}
+
+//FinalizeInput builds the final scriptSig and witness of the i-th input from
+//its signatures, and removes the fields which are not needed any more.
+//Inputs which are already finalized are left as they are. It returns
+//ErrMissingRedeemScript or ErrMissingWitnessScript if the script doesn't
+//hash to the previous output.
+func (p *Packet) FinalizeInput(i int) error {
+	in := p.Inputs[i]
+	if in.FinalScriptSig != nil || in.FinalScriptWitness != nil {
+		return nil
+	}
+	prev := p.prevOut(i)
+	if prev == nil {
+		return ErrMissingUtxo
+	}
+	script := prev.PkScript
+
+	var scriptSig []byte
+	var witness [][]byte
+	if isP2TR(script) {
+		if in.TaprootKeySig == nil {
+			return ErrNotFinalizable
+		}
+		witness = [][]byte{in.TaprootKeySig}
+	} else {
+		var redeemScript []byte
+		if isP2SH(script) {
+			if in.RedeemScript == nil ||
+				!bytes.Equal(script[2:22], address.AddressBytes(in.RedeemScript)) {
+				return ErrMissingRedeemScript
+			}
+			script = in.RedeemScript
+			redeemScript = in.RedeemScript
+		}
+		switch {
+		case isP2WPKH(script):
+			s := in.findSig(func(pub []byte) bool {
+				return bytes.Equal(script[2:], address.AddressBytes(pub))
+			})
+			if s == nil {
+				return ErrNotFinalizable
+			}
+			witness = [][]byte{s.Signature, s.PubKey}
+		case isP2WSH(script):
+			h := sha256.Sum256(in.WitnessScript)
+			if in.WitnessScript == nil || !bytes.Equal(script[2:], h[:]) {
+				return ErrMissingWitnessScript
+			}
+			sigs, err := in.multisigSigs(in.WitnessScript)
+			if err != nil {
+				return err
+			}
+			witness = append([][]byte{{}}, sigs...)
+			witness = append(witness, in.WitnessScript)
+		case isP2PKH(script):
+			s := in.findSig(func(pub []byte) bool {
+				return bytes.Equal(script[3:23], address.AddressBytes(pub))
+			})
+			if s == nil {
+				return ErrNotFinalizable
+			}
+			scriptSig = append(pushData(s.Signature), pushData(s.PubKey)...)
+		default:
+			sigs, err := in.multisigSigs(script)
+			if err != nil {
+				return err
+			}
+			scriptSig = []byte{op0}
+			for _, s := range sigs {
+				scriptSig = append(scriptSig, pushData(s)...)
+			}
+		}
+		if redeemScript != nil {
+			scriptSig = append(scriptSig, pushData(redeemScript)...)
+		}
+	}
+
+	in.FinalScriptSig = scriptSig
+	in.FinalScriptWitness = witness
+	in.PartialSigs = nil
+	in.SighashType = nil
+	in.RedeemScript = nil
+	in.WitnessScript = nil
+	in.Bip32Derivation = nil
+	in.TaprootKeySig = nil
+	in.TaprootBip32Derivation = nil
+	in.TaprootInternalKey = nil
+	in.TaprootMerkleRoot = nil
+	var unknowns []*Unknown
+	for _, u := range in.Unknowns {
+		if u.Key[0] != inTapScriptSig && u.Key[0] != inTapLeafScript {
+			unknowns = append(unknowns, u)
+		}
+	}
+	in.Unknowns = unknowns
+	return nil
+}
+
+//findSig returns the partial signature whose public key satisfies match.
+func (in *Input) findSig(match func([]byte) bool) *PartialSig {
+	for _, s := range in.PartialSigs {
+		if match(s.PubKey) {
+			return s
+		}
+	}
+	return nil
+}
+
+//multisigSigs returns the required number of signatures of the multisig
+//script in the order of the public keys.
+func (in *Input) multisigSigs(script []byte) ([][]byte, error) {
+	m, pubs, ok := parseMultisig(script)
+	if !ok {
+		return nil, ErrNotFinalizable
+	}
+	var sigs [][]byte
+	for _, pub := range pubs {
+		if len(sigs) == m {
+			break
+		}
+		s := in.findSig(func(pk []byte) bool {
+			return bytes.Equal(pk, pub)
+		})
+		if s != nil {
+			sigs = append(sigs, s.Signature)
+		}
+	}
+	if len(sigs) < m {
+		return nil, ErrNotFinalizable
+	}
+	return sigs, nil
+}
+
+//Extract returns the signed transaction of the PSBT whose inputs are all
+//finalized.
+func (p *Packet) Extract() (*address.Tx, error) {
+	tx, err := p.Tx()
+	if err != nil {
+		return nil, err
+	}
+	for i, in := range p.Inputs {
+		if in.FinalScriptSig == nil && in.FinalScriptWitness == nil {
+			return nil, ErrNotFinalized
+		}
+		tx.TxIn[i].SignatureScript = in.FinalScriptSig
+		tx.TxIn[i].Witness = in.FinalScriptWitness
+	}
+	return tx, nil
+}
@@ -0,0 +1,991 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+//Package psbt implements partially signed bitcoin transactions of BIP174
+//(version 0) and BIP370 (version 2), which are signed with the keys of
+//package address.
+package psbt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/bitgoin/address"
+	"github.com/bitgoin/address/btcec"
+)
+
+//magic is the magic bytes at the beginning of PSBTs.
+var magic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+//types of the global map.
+const (
+	globalUnsignedTx       = 0x00
+	globalXPub             = 0x01
+	globalTxVersion        = 0x02
+	globalFallbackLocktime = 0x03
+	globalInputCount       = 0x04
+	globalOutputCount      = 0x05
+	globalTxModifiable     = 0x06
+	globalVersion          = 0xfb
+)
+
+//types of input maps.
+const (
+	inNonWitnessUtxo         = 0x00
+	inWitnessUtxo            = 0x01
+	inPartialSig             = 0x02
+	inSighashType            = 0x03
+	inRedeemScript           = 0x04
+	inWitnessScript          = 0x05
+	inBip32Derivation        = 0x06
+	inFinalScriptSig         = 0x07
+	inFinalScriptWitness     = 0x08
+	inPreviousTxid           = 0x0e
+	inOutputIndex            = 0x0f
+	inSequence               = 0x10
+	inRequiredTimeLocktime   = 0x11
+	inRequiredHeightLocktime = 0x12
+	inTapKeySig              = 0x13
+	inTapScriptSig           = 0x14
+	inTapLeafScript          = 0x15
+	inTapBip32Derivation     = 0x16
+	inTapInternalKey         = 0x17
+	inTapMerkleRoot          = 0x18
+)
+
+//types of output maps.
+const (
+	outRedeemScript       = 0x00
+	outWitnessScript      = 0x01
+	outBip32Derivation    = 0x02
+	outAmount             = 0x03
+	outScript             = 0x04
+	outTapInternalKey     = 0x05
+	outTapTree            = 0x06
+	outTapBip32Derivation = 0x07
+)
+
+//locktimeThreshold is the border between block heights and unix times of
+//locktimes.
+const locktimeThreshold = 500000000
+
+//errors
+var (
+	ErrInvalidMagic       = errors.New("invalid psbt magic bytes")
+	ErrInvalidPsbtFormat  = errors.New("invalid psbt format")
+	ErrDuplicateKey       = errors.New("duplicate key in psbt map")
+	ErrInvalidKeyData     = errors.New("invalid key data of psbt field")
+	ErrInvalidValue       = errors.New("invalid value of psbt field")
+	ErrUnsupportedVersion = errors.New("unsupported psbt version")
+	ErrMissingField       = errors.New("required psbt field is missing")
+	ErrForbiddenField     = errors.New("psbt field is not allowed in the version")
+	ErrUtxoMismatch       = errors.New("non-witness utxo does not match the input")
+	ErrLocktimeConflict   = errors.New("required locktimes of inputs conflict")
+)
+
+//Bip32Derivation is the BIP32 derivation path of a public key from the
+//master key with Fingerprint.
+type Bip32Derivation struct {
+	PubKey      []byte
+	Fingerprint uint32
+	Path        address.DerivationPath
+}
+
+//TaprootBip32Derivation is the BIP32 derivation path of a x-only public key
+//and the hashes of the leaves which the key is used in.
+type TaprootBip32Derivation struct {
+	XOnlyPubKey []byte
+	LeafHashes  [][]byte
+	Fingerprint uint32
+	Path        address.DerivationPath
+}
+
+//XPub is a serialized extended public key and its derivation path from
+//the master key with Fingerprint.
+type XPub struct {
+	ExtendedKey []byte
+	Fingerprint uint32
+	Path        address.DerivationPath
+}
+
+//PartialSig is a signature of a public key with the sighash type byte.
+type PartialSig struct {
+	PubKey    []byte
+	Signature []byte
+}
+
+//Unknown is a field whose type is not known by the package. Key includes
+//the type.
+type Unknown struct {
+	Key   []byte
+	Value []byte
+}
+
+//Packet is a PSBT.
+type Packet struct {
+	//Version is the version of the PSBT, 0 or 2.
+	Version uint32
+	//UnsignedTx is the transaction of a version 0 PSBT. It is nil in version 2.
+	UnsignedTx *address.Tx
+	XPubs      []*XPub
+
+	//TxVersion, FallbackLocktime and TxModifiable are fields of version 2.
+	TxVersion        int32
+	FallbackLocktime *uint32
+	TxModifiable     *byte
+
+	Inputs   []*Input
+	Outputs  []*Output
+	Unknowns []*Unknown
+}
+
+//Input is an input map of a PSBT. Optional fields are nil if not present.
+type Input struct {
+	NonWitnessUtxo     *address.Tx
+	WitnessUtxo        *address.TxOut
+	PartialSigs        []*PartialSig
+	SighashType        *address.SigHashType
+	RedeemScript       []byte
+	WitnessScript      []byte
+	Bip32Derivation    []*Bip32Derivation
+	FinalScriptSig     []byte
+	FinalScriptWitness [][]byte
+
+	//PreviousTxid, OutputIndex, Sequence, RequiredTimeLocktime and
+	//RequiredHeightLocktime are fields of version 2.
+	PreviousTxid           []byte
+	OutputIndex            uint32
+	Sequence               *uint32
+	RequiredTimeLocktime   *uint32
+	RequiredHeightLocktime *uint32
+
+	TaprootKeySig          []byte
+	TaprootBip32Derivation []*TaprootBip32Derivation
+	TaprootInternalKey     []byte
+	TaprootMerkleRoot      []byte
+
+	Unknowns []*Unknown
+}
+
+//Output is an output map of a PSBT.
+type Output struct {
+	RedeemScript    []byte
+	WitnessScript   []byte
+	Bip32Derivation []*Bip32Derivation
+
+	//Amount and Script are fields of version 2.
+	Amount int64
+	Script []byte
+
+	TaprootInternalKey     []byte
+	TaprootTree            []byte
+	TaprootBip32Derivation []*TaprootBip32Derivation
+
+	Unknowns []*Unknown
+}
+
+//keyValue is a pair of a raw key and value of a map.
+type keyValue struct {
+	key   []byte
+	value []byte
+}
+
+//readMap reads pairs of a map until the separator.
+func readMap(r *bytes.Reader) ([]*keyValue, error) {
+	var kvs []*keyValue
+	seen := make(map[string]bool)
+	for {
+		key, err := readVarBytes(r)
+		if err != nil {
+			return nil, ErrInvalidPsbtFormat
+		}
+		if len(key) == 0 {
+			return kvs, nil
+		}
+		value, err := readVarBytes(r)
+		if err != nil {
+			return nil, ErrInvalidPsbtFormat
+		}
+		if seen[string(key)] {
+			return nil, ErrDuplicateKey
+		}
+		seen[string(key)] = true
+		kvs = append(kvs, &keyValue{key: key, value: value})
+	}
+}
+
+func writeKV(w *bytes.Buffer, value []byte, typ byte, keydata ...byte) {
+	writeVarBytes(w, append([]byte{typ}, keydata...))
+	writeVarBytes(w, value)
+}
+
+func uint32Bytes(v uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	return b[:]
+}
+
+//uint32 returns the value of the field with no key data as uint32.
+func (kv *keyValue) uint32() (*uint32, error) {
+	if len(kv.key) != 1 {
+		return nil, ErrInvalidKeyData
+	}
+	if len(kv.value) != 4 {
+		return nil, ErrInvalidValue
+	}
+	v := binary.LittleEndian.Uint32(kv.value)
+	return &v, nil
+}
+
+//bytes returns the value of the field with no key data, which must be l
+//bytes if l is not zero.
+func (kv *keyValue) bytes(l int) ([]byte, error) {
+	if len(kv.key) != 1 {
+		return nil, ErrInvalidKeyData
+	}
+	if l != 0 && len(kv.value) != l {
+		return nil, ErrInvalidValue
+	}
+	return kv.value, nil
+}
+
+func (kv *keyValue) unknown() *Unknown {
+	return &Unknown{Key: kv.key, Value: kv.value}
+}
+
+//isValidPubKey returns true if b is a valid compressed or uncompressed
+//public key.
+func isValidPubKey(b []byte) bool {
+	if len(b) != btcec.PubKeyBytesLenCompressed &&
+		len(b) != btcec.PubKeyBytesLenUncompressed {
+		return false
+	}
+	_, err := btcec.ParsePubKey(b, btcec.S256())
+	return err == nil
+}
+
+func checkXOnlyPubKey(b []byte) error {
+	if _, err := btcec.ParseXOnlyPubKey(b); err != nil {
+		return ErrInvalidValue
+	}
+	return nil
+}
+
+func parseKeyOrigin(b []byte) (uint32, address.DerivationPath, error) {
+	if len(b) < 4 || len(b)%4 != 0 {
+		return 0, nil, ErrInvalidValue
+	}
+	path := make(address.DerivationPath, 0, len(b)/4-1)
+	for i := 4; i < len(b); i += 4 {
+		path = append(path, binary.LittleEndian.Uint32(b[i:]))
+	}
+	return binary.BigEndian.Uint32(b), path, nil
+}
+
+func serializeKeyOrigin(fp uint32, path address.DerivationPath) []byte {
+	b := make([]byte, 4, 4+4*len(path))
+	binary.BigEndian.PutUint32(b, fp)
+	for _, p := range path {
+		b = append(b, uint32Bytes(p)...)
+	}
+	return b
+}
+
+func parseBip32Derivation(kv *keyValue) (*Bip32Derivation, error) {
+	if !isValidPubKey(kv.key[1:]) {
+		return nil, ErrInvalidKeyData
+	}
+	fp, path, err := parseKeyOrigin(kv.value)
+	if err != nil {
+		return nil, err
+	}
+	return &Bip32Derivation{PubKey: kv.key[1:], Fingerprint: fp, Path: path}, nil
+}
+
+func parseTaprootBip32Derivation(kv *keyValue) (*TaprootBip32Derivation, error) {
+	if _, err := btcec.ParseXOnlyPubKey(kv.key[1:]); err != nil {
+		return nil, ErrInvalidKeyData
+	}
+	r := bytes.NewReader(kv.value)
+	n, err := readVarInt(r)
+	if err != nil || n > uint64(r.Len())/32 {
+		return nil, ErrInvalidValue
+	}
+	d := &TaprootBip32Derivation{XOnlyPubKey: kv.key[1:]}
+	for i := uint64(0); i < n; i++ {
+		h := make([]byte, 32)
+		if _, err := io.ReadFull(r, h); err != nil {
+			return nil, ErrInvalidValue
+		}
+		d.LeafHashes = append(d.LeafHashes, h)
+	}
+	rest := make([]byte, r.Len())
+	copy(rest, kv.value[len(kv.value)-r.Len():])
+	d.Fingerprint, d.Path, err = parseKeyOrigin(rest)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *TaprootBip32Derivation) serialize() []byte {
+	var buf bytes.Buffer
+	writeVarInt(&buf, uint64(len(d.LeafHashes)))
+	for _, h := range d.LeafHashes {
+		buf.Write(h)
+	}
+	buf.Write(serializeKeyOrigin(d.Fingerprint, d.Path))
+	return buf.Bytes()
+}
+
+//Parse parses a serialized PSBT.
+func Parse(b []byte) (*Packet, error) {
+	if !bytes.HasPrefix(b, magic) {
+		return nil, ErrInvalidMagic
+	}
+	r := bytes.NewReader(b[len(magic):])
+	kvs, err := readMap(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &Packet{}
+	nin, nout, err := p.parseGlobals(kvs)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < nin; i++ {
+		kvs, err := readMap(r)
+		if err != nil {
+			return nil, err
+		}
+		in, err := parseInput(kvs, p.Version)
+		if err != nil {
+			return nil, err
+		}
+		p.Inputs = append(p.Inputs, in)
+	}
+	for i := uint64(0); i < nout; i++ {
+		kvs, err := readMap(r)
+		if err != nil {
+			return nil, err
+		}
+		out, err := parseOutput(kvs, p.Version)
+		if err != nil {
+			return nil, err
+		}
+		p.Outputs = append(p.Outputs, out)
+	}
+	if r.Len() != 0 {
+		return nil, ErrInvalidPsbtFormat
+	}
+	if err := p.checkUtxos(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+//ParseBase64 parses a base64 encoded PSBT.
+func ParseBase64(s string) (*Packet, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+//parseGlobals parses the global map and returns the number of inputs and
+//outputs.
+func (p *Packet) parseGlobals(kvs []*keyValue) (uint64, uint64, error) {
+	var nin, nout *uint64
+	var txVersion *uint32
+	v2only := false
+	for _, kv := range kvs {
+		var err error
+		switch kv.key[0] {
+		case globalUnsignedTx:
+			var b []byte
+			if b, err = kv.bytes(0); err != nil {
+				break
+			}
+			if p.UnsignedTx, err = address.ParseTxNoWitness(b); err != nil {
+				break
+			}
+			for _, in := range p.UnsignedTx.TxIn {
+				if len(in.SignatureScript) != 0 || len(in.Witness) != 0 {
+					err = ErrInvalidValue
+				}
+			}
+		case globalXPub:
+			if len(kv.key) != 1+78 {
+				err = ErrInvalidKeyData
+				break
+			}
+			x := &XPub{ExtendedKey: kv.key[1:]}
+			x.Fingerprint, x.Path, err = parseKeyOrigin(kv.value)
+			p.XPubs = append(p.XPubs, x)
+		case globalTxVersion:
+			txVersion, err = kv.uint32()
+			v2only = true
+		case globalFallbackLocktime:
+			p.FallbackLocktime, err = kv.uint32()
+			v2only = true
+		case globalInputCount, globalOutputCount:
+			if len(kv.key) != 1 {
+				err = ErrInvalidKeyData
+				break
+			}
+			r := bytes.NewReader(kv.value)
+			n, errv := readVarInt(r)
+			if errv != nil || r.Len() != 0 || n > maxItems {
+				err = ErrInvalidValue
+				break
+			}
+			if kv.key[0] == globalInputCount {
+				nin = &n
+			} else {
+				nout = &n
+			}
+			v2only = true
+		case globalTxModifiable:
+			var b []byte
+			if b, err = kv.bytes(1); err == nil {
+				p.TxModifiable = &b[0]
+			}
+			v2only = true
+		case globalVersion:
+			var v *uint32
+			if v, err = kv.uint32(); err == nil {
+				p.Version = *v
+			}
+		default:
+			p.Unknowns = append(p.Unknowns, kv.unknown())
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	switch p.Version {
+	case 0:
+		if p.UnsignedTx == nil {
+			return 0, 0, ErrMissingField
+		}
+		if v2only {
+			return 0, 0, ErrForbiddenField
+		}
+		return uint64(len(p.UnsignedTx.TxIn)), uint64(len(p.UnsignedTx.TxOut)), nil
+	case 2:
+		if p.UnsignedTx != nil {
+			return 0, 0, ErrForbiddenField
+		}
+		if txVersion == nil || nin == nil || nout == nil {
+			return 0, 0, ErrMissingField
+		}
+		if int32(*txVersion) < 2 {
+			return 0, 0, ErrInvalidValue
+		}
+		p.TxVersion = int32(*txVersion)
+		return *nin, *nout, nil
+	default:
+		return 0, 0, ErrUnsupportedVersion
+	}
+}
+
+func parseInput(kvs []*keyValue, version uint32) (*Input, error) {
+	in := &Input{}
+	hasTxid, hasIndex := false, false
+	for _, kv := range kvs {
+		var err error
+		typ := kv.key[0]
+		//fields of version 2 are excluded from version 0, while the types
+		//with key data are unknown ones.
+		if version == 0 && typ >= inPreviousTxid && typ <= inRequiredHeightLocktime {
+			if len(kv.key) == 1 {
+				return nil, ErrForbiddenField
+			}
+			typ = 0xff
+		}
+		switch typ {
+		case inNonWitnessUtxo:
+			var b []byte
+			if b, err = kv.bytes(0); err == nil {
+				in.NonWitnessUtxo, err = address.ParseTx(b)
+			}
+		case inWitnessUtxo:
+			var b []byte
+			if b, err = kv.bytes(0); err != nil {
+				break
+			}
+			if in.WitnessUtxo, err = address.ParseTxOut(b); err != nil {
+				err = ErrInvalidValue
+			}
+		case inPartialSig:
+			if !isValidPubKey(kv.key[1:]) {
+				err = ErrInvalidKeyData
+				break
+			}
+			in.PartialSigs = append(in.PartialSigs, &PartialSig{
+				PubKey:    kv.key[1:],
+				Signature: kv.value,
+			})
+		case inSighashType:
+			var v *uint32
+			if v, err = kv.uint32(); err == nil {
+				t := address.SigHashType(*v)
+				in.SighashType = &t
+			}
+		case inRedeemScript:
+			in.RedeemScript, err = kv.bytes(0)
+		case inWitnessScript:
+			in.WitnessScript, err = kv.bytes(0)
+		case inBip32Derivation:
+			var d *Bip32Derivation
+			if d, err = parseBip32Derivation(kv); err == nil {
+				in.Bip32Derivation = append(in.Bip32Derivation, d)
+			}
+		case inFinalScriptSig:
+			in.FinalScriptSig, err = kv.bytes(0)
+		case inFinalScriptWitness:
+			var b []byte
+			if b, err = kv.bytes(0); err != nil {
+				break
+			}
+			r := bytes.NewReader(b)
+			if in.FinalScriptWitness, err = readWitness(r); err != nil || r.Len() != 0 {
+				err = ErrInvalidValue
+			}
+		case inPreviousTxid:
+			in.PreviousTxid, err = kv.bytes(32)
+			hasTxid = true
+		case inOutputIndex:
+			var v *uint32
+			if v, err = kv.uint32(); err == nil {
+				in.OutputIndex = *v
+			}
+			hasIndex = true
+		case inSequence:
+			in.Sequence, err = kv.uint32()
+		case inRequiredTimeLocktime:
+			in.RequiredTimeLocktime, err = kv.uint32()
+			if err == nil && *in.RequiredTimeLocktime < locktimeThreshold {
+				err = ErrInvalidValue
+			}
+		case inRequiredHeightLocktime:
+			in.RequiredHeightLocktime, err = kv.uint32()
+			if err == nil && (*in.RequiredHeightLocktime == 0 ||
+				*in.RequiredHeightLocktime >= locktimeThreshold) {
+				err = ErrInvalidValue
+			}
+		case inTapKeySig:
+			if in.TaprootKeySig, err = kv.bytes(0); err == nil &&
+				len(kv.value) != 64 && len(kv.value) != 65 {
+				err = ErrInvalidValue
+			}
+		case inTapScriptSig:
+			switch {
+			case len(kv.key) != 1+32+32:
+				err = ErrInvalidKeyData
+			case len(kv.value) != 64 && len(kv.value) != 65:
+				err = ErrInvalidValue
+			default:
+				in.Unknowns = append(in.Unknowns, kv.unknown())
+			}
+		case inTapLeafScript:
+			if len(kv.key) < 1+33 || (len(kv.key)-1-33)%32 != 0 {
+				err = ErrInvalidKeyData
+				break
+			}
+			in.Unknowns = append(in.Unknowns, kv.unknown())
+		case inTapBip32Derivation:
+			var d *TaprootBip32Derivation
+			if d, err = parseTaprootBip32Derivation(kv); err == nil {
+				in.TaprootBip32Derivation = append(in.TaprootBip32Derivation, d)
+			}
+		case inTapInternalKey:
+			if in.TaprootInternalKey, err = kv.bytes(32); err == nil {
+				err = checkXOnlyPubKey(kv.value)
+			}
+		case inTapMerkleRoot:
+			in.TaprootMerkleRoot, err = kv.bytes(32)
+		default:
+			in.Unknowns = append(in.Unknowns, kv.unknown())
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if version == 2 && (!hasTxid || !hasIndex) {
+		return nil, ErrMissingField
+	}
+	return in, nil
+}
+
+func parseOutput(kvs []*keyValue, version uint32) (*Output, error) {
+	out := &Output{}
+	hasAmount, hasScript := false, false
+	for _, kv := range kvs {
+		var err error
+		typ := kv.key[0]
+		if version == 0 && (typ == outAmount || typ == outScript) {
+			if len(kv.key) == 1 {
+				return nil, ErrForbiddenField
+			}
+			typ = 0xff
+		}
+		switch typ {
+		case outRedeemScript:
+			out.RedeemScript, err = kv.bytes(0)
+		case outWitnessScript:
+			out.WitnessScript, err = kv.bytes(0)
+		case outBip32Derivation:
+			var d *Bip32Derivation
+			if d, err = parseBip32Derivation(kv); err == nil {
+				out.Bip32Derivation = append(out.Bip32Derivation, d)
+			}
+		case outAmount:
+			var b []byte
+			if b, err = kv.bytes(8); err == nil {
+				out.Amount = int64(binary.LittleEndian.Uint64(b))
+			}
+			hasAmount = true
+		case outScript:
+			out.Script, err = kv.bytes(0)
+			hasScript = true
+		case outTapInternalKey:
+			if out.TaprootInternalKey, err = kv.bytes(32); err == nil {
+				err = checkXOnlyPubKey(kv.value)
+			}
+		case outTapTree:
+			if out.TaprootTree, err = kv.bytes(0); err == nil && len(kv.value) == 0 {
+				err = ErrInvalidValue
+			}
+		case outTapBip32Derivation:
+			var d *TaprootBip32Derivation
+			if d, err = parseTaprootBip32Derivation(kv); err == nil {
+				out.TaprootBip32Derivation = append(out.TaprootBip32Derivation, d)
+			}
+		default:
+			out.Unknowns = append(out.Unknowns, kv.unknown())
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if version == 2 && (!hasAmount || !hasScript) {
+		return nil, ErrMissingField
+	}
+	return out, nil
+}
+
+//checkUtxos checks that non-witness utxos are the previous transactions of
+//the inputs.
+func (p *Packet) checkUtxos() error {
+	for i, in := range p.Inputs {
+		if in.NonWitnessUtxo == nil {
+			continue
+		}
+		op := p.outPoint(i)
+		if !bytes.Equal(in.NonWitnessUtxo.TxHash(), op.Hash) ||
+			int(op.Index) >= len(in.NonWitnessUtxo.TxOut) {
+			return ErrUtxoMismatch
+		}
+	}
+	return nil
+}
+
+//outPoint returns the previous output point of the i-th input.
+func (p *Packet) outPoint(i int) address.OutPoint {
+	if p.Version == 0 {
+		return p.UnsignedTx.TxIn[i].PreviousOutPoint
+	}
+	return address.OutPoint{Hash: p.Inputs[i].PreviousTxid, Index: p.Inputs[i].OutputIndex}
+}
+
+//prevOut returns the output spent by the i-th input, or nil if the PSBT
+//doesn't have the utxo.
+func (p *Packet) prevOut(i int) *address.TxOut {
+	in := p.Inputs[i]
+	if in.WitnessUtxo != nil {
+		return in.WitnessUtxo
+	}
+	if in.NonWitnessUtxo != nil {
+		return in.NonWitnessUtxo.TxOut[p.outPoint(i).Index]
+	}
+	return nil
+}
+
+//Tx returns the unsigned transaction of the PSBT. For version 2 it is
+//constructed from the inputs and outputs as described in BIP370.
+func (p *Packet) Tx() (*address.Tx, error) {
+	if p.Version == 0 {
+		return p.UnsignedTx.Copy(), nil
+	}
+	tx := &address.Tx{Version: p.TxVersion}
+	for i, in := range p.Inputs {
+		seq := uint32(0xffffffff)
+		if in.Sequence != nil {
+			seq = *in.Sequence
+		}
+		op := p.outPoint(i)
+		tx.TxIn = append(tx.TxIn, &address.TxIn{
+			PreviousOutPoint: address.OutPoint{
+				Hash:  append([]byte{}, op.Hash...),
+				Index: op.Index,
+			},
+			Sequence: seq,
+		})
+	}
+	for _, out := range p.Outputs {
+		tx.TxOut = append(tx.TxOut, &address.TxOut{
+			Value:    out.Amount,
+			PkScript: append([]byte{}, out.Script...),
+		})
+	}
+	var err error
+	tx.LockTime, err = p.locktime()
+	return tx, err
+}
+
+//locktime determines the locktime of a version 2 PSBT.
+func (p *Packet) locktime() (uint32, error) {
+	var height, time uint32
+	hasLocktime, heightOK, timeOK := false, true, true
+	for _, in := range p.Inputs {
+		if in.RequiredHeightLocktime == nil && in.RequiredTimeLocktime == nil {
+			continue
+		}
+		hasLocktime = true
+		if in.RequiredHeightLocktime == nil {
+			heightOK = false
+		} else if *in.RequiredHeightLocktime > height {
+			height = *in.RequiredHeightLocktime
+		}
+		if in.RequiredTimeLocktime == nil {
+			timeOK = false
+		} else if *in.RequiredTimeLocktime > time {
+			time = *in.RequiredTimeLocktime
+		}
+	}
+	switch {
+	case !hasLocktime:
+		if p.FallbackLocktime != nil {
+			return *p.FallbackLocktime, nil
+		}
+		return 0, nil
+	case heightOK:
+		return height, nil
+	case timeOK:
+		return time, nil
+	default:
+		return 0, ErrLocktimeConflict
+	}
+}
+
+//Serialize serializes the PSBT.
+func (p *Packet) Serialize() ([]byte, error) {
+	if p.Version != 0 && p.Version != 2 {
+		return nil, ErrUnsupportedVersion
+	}
+	if p.Version == 0 && (p.UnsignedTx == nil ||
+		len(p.UnsignedTx.TxIn) != len(p.Inputs) ||
+		len(p.UnsignedTx.TxOut) != len(p.Outputs)) {
+		return nil, ErrInvalidPsbtFormat
+	}
+	var w bytes.Buffer
+	w.Write(magic)
+	p.serializeGlobals(&w)
+	for _, in := range p.Inputs {
+		in.serialize(&w)
+	}
+	for _, out := range p.Outputs {
+		out.serialize(&w, p.Version)
+	}
+	return w.Bytes(), nil
+}
+
+//B64Encode returns the base64 encoded PSBT.
+func (p *Packet) B64Encode() (string, error) {
+	b, err := p.Serialize()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func writeUnknowns(w *bytes.Buffer, us []*Unknown) {
+	for _, u := range us {
+		writeVarBytes(w, u.Key)
+		writeVarBytes(w, u.Value)
+	}
+	w.WriteByte(0x00)
+}
+
+func (p *Packet) serializeGlobals(w *bytes.Buffer) {
+	if p.Version == 0 {
+		writeKV(w, p.UnsignedTx.SerializeNoWitness(), globalUnsignedTx)
+	}
+	xpubs := append([]*XPub{}, p.XPubs...)
+	sort.Slice(xpubs, func(i, j int) bool {
+		return bytes.Compare(xpubs[i].ExtendedKey, xpubs[j].ExtendedKey) < 0
+	})
+	for _, x := range xpubs {
+		writeKV(w, serializeKeyOrigin(x.Fingerprint, x.Path), globalXPub, x.ExtendedKey...)
+	}
+	if p.Version == 2 {
+		var buf bytes.Buffer
+		writeKV(w, uint32Bytes(uint32(p.TxVersion)), globalTxVersion)
+		if p.FallbackLocktime != nil {
+			writeKV(w, uint32Bytes(*p.FallbackLocktime), globalFallbackLocktime)
+		}
+		writeVarInt(&buf, uint64(len(p.Inputs)))
+		writeKV(w, buf.Bytes(), globalInputCount)
+		buf.Reset()
+		writeVarInt(&buf, uint64(len(p.Outputs)))
+		writeKV(w, buf.Bytes(), globalOutputCount)
+		if p.TxModifiable != nil {
+			writeKV(w, []byte{*p.TxModifiable}, globalTxModifiable)
+		}
+		writeKV(w, uint32Bytes(p.Version), globalVersion)
+	}
+	writeUnknowns(w, p.Unknowns)
+}
+
+func sortBip32Derivation(ds []*Bip32Derivation) []*Bip32Derivation {
+	ds = append([]*Bip32Derivation{}, ds...)
+	sort.Slice(ds, func(i, j int) bool {
+		return bytes.Compare(ds[i].PubKey, ds[j].PubKey) < 0
+	})
+	return ds
+}
+
+func sortTaprootBip32Derivation(ds []*TaprootBip32Derivation) []*TaprootBip32Derivation {
+	ds = append([]*TaprootBip32Derivation{}, ds...)
+	sort.Slice(ds, func(i, j int) bool {
+		return bytes.Compare(ds[i].XOnlyPubKey, ds[j].XOnlyPubKey) < 0
+	})
+	return ds
+}
+
+//serialize writes the input map. Fields are written in the order of the
+//types except that final scriptSig and witness are written last, like
+//Bitcoin Core does.
+func (in *Input) serialize(w *bytes.Buffer) {
+	if in.NonWitnessUtxo != nil {
+		writeKV(w, in.NonWitnessUtxo.Serialize(), inNonWitnessUtxo)
+	}
+	if in.WitnessUtxo != nil {
+		writeKV(w, in.WitnessUtxo.Serialize(), inWitnessUtxo)
+	}
+	//Bitcoin Core sorts signatures by the hash160 of public keys.
+	sigs := append([]*PartialSig{}, in.PartialSigs...)
+	sort.Slice(sigs, func(i, j int) bool {
+		return bytes.Compare(address.AddressBytes(sigs[i].PubKey),
+			address.AddressBytes(sigs[j].PubKey)) < 0
+	})
+	for _, s := range sigs {
+		writeKV(w, s.Signature, inPartialSig, s.PubKey...)
+	}
+	if in.SighashType != nil {
+		writeKV(w, uint32Bytes(uint32(*in.SighashType)), inSighashType)
+	}
+	if in.RedeemScript != nil {
+		writeKV(w, in.RedeemScript, inRedeemScript)
+	}
+	if in.WitnessScript != nil {
+		writeKV(w, in.WitnessScript, inWitnessScript)
+	}
+	for _, d := range sortBip32Derivation(in.Bip32Derivation) {
+		writeKV(w, serializeKeyOrigin(d.Fingerprint, d.Path), inBip32Derivation, d.PubKey...)
+	}
+	if in.PreviousTxid != nil {
+		writeKV(w, in.PreviousTxid, inPreviousTxid)
+		writeKV(w, uint32Bytes(in.OutputIndex), inOutputIndex)
+	}
+	if in.Sequence != nil {
+		writeKV(w, uint32Bytes(*in.Sequence), inSequence)
+	}
+	if in.RequiredTimeLocktime != nil {
+		writeKV(w, uint32Bytes(*in.RequiredTimeLocktime), inRequiredTimeLocktime)
+	}
+	if in.RequiredHeightLocktime != nil {
+		writeKV(w, uint32Bytes(*in.RequiredHeightLocktime), inRequiredHeightLocktime)
+	}
+	if in.TaprootKeySig != nil {
+		writeKV(w, in.TaprootKeySig, inTapKeySig)
+	}
+	for _, d := range sortTaprootBip32Derivation(in.TaprootBip32Derivation) {
+		writeKV(w, d.serialize(), inTapBip32Derivation, d.XOnlyPubKey...)
+	}
+	if in.TaprootInternalKey != nil {
+		writeKV(w, in.TaprootInternalKey, inTapInternalKey)
+	}
+	if in.TaprootMerkleRoot != nil {
+		writeKV(w, in.TaprootMerkleRoot, inTapMerkleRoot)
+	}
+	if in.FinalScriptSig != nil {
+		writeKV(w, in.FinalScriptSig, inFinalScriptSig)
+	}
+	if in.FinalScriptWitness != nil {
+		var buf bytes.Buffer
+		writeWitness(&buf, in.FinalScriptWitness)
+		writeKV(w, buf.Bytes(), inFinalScriptWitness)
+	}
+	writeUnknowns(w, in.Unknowns)
+}
+
+func (out *Output) serialize(w *bytes.Buffer, version uint32) {
+	if out.RedeemScript != nil {
+		writeKV(w, out.RedeemScript, outRedeemScript)
+	}
+	if out.WitnessScript != nil {
+		writeKV(w, out.WitnessScript, outWitnessScript)
+	}
+	for _, d := range sortBip32Derivation(out.Bip32Derivation) {
+		writeKV(w, serializeKeyOrigin(d.Fingerprint, d.Path), outBip32Derivation, d.PubKey...)
+	}
+	if version == 2 {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(out.Amount))
+		writeKV(w, b[:], outAmount)
+		writeKV(w, out.Script, outScript)
+	}
+	if out.TaprootInternalKey != nil {
+		writeKV(w, out.TaprootInternalKey, outTapInternalKey)
+	}
+	if out.TaprootTree != nil {
+		writeKV(w, out.TaprootTree, outTapTree)
+	}
+	for _, d := range sortTaprootBip32Derivation(out.TaprootBip32Derivation) {
+		writeKV(w, d.serialize(), outTapBip32Derivation, d.XOnlyPubKey...)
+	}
+	writeUnknowns(w, out.Unknowns)
+}
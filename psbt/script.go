@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package psbt
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/bitgoin/address"
+)
+
+//opcodes used in standard scripts.
+const (
+	op0           = 0x00
+	opPushData1   = 0x4c
+	opPushData2   = 0x4d
+	op1           = 0x51
+	op16          = 0x60
+	opDup         = 0x76
+	opEqual       = 0x87
+	opEqualVerify = 0x88
+	opHash160     = 0xa9
+	opCheckSig    = 0xac
+)
+
+func isP2PKH(s []byte) bool {
+	return len(s) == 25 && s[0] == opDup && s[1] == opHash160 &&
+		s[2] == 20 && s[23] == opEqualVerify && s[24] == opCheckSig
+}
+
+func isP2SH(s []byte) bool {
+	return len(s) == 23 && s[0] == opHash160 && s[1] == 20 && s[22] == opEqual
+}
+
+func isP2WPKH(s []byte) bool {
+	return len(s) == 22 && s[0] == op0 && s[1] == 20
+}
+
+func isP2WSH(s []byte) bool {
+	return len(s) == 34 && s[0] == op0 && s[1] == 32
+}
+
+func isP2TR(s []byte) bool {
+	return len(s) == 34 && s[0] == op1 && s[1] == 32
+}
+
+func isWitnessProgram(s []byte) bool {
+	return len(s) >= 4 && len(s) <= 42 &&
+		(s[0] == op0 || (s[0] >= op1 && s[0] <= op16)) && int(s[1]) == len(s)-2
+}
+
+//p2pkhScript returns the P2PKH script of the hash, which is also the
+//script code of P2WPKH in BIP143.
+func p2pkhScript(hash []byte) []byte {
+	s := []byte{opDup, opHash160, 20}
+	s = append(s, hash...)
+	return append(s, opEqualVerify, opCheckSig)
+}
+
+//parseMultisig returns the number of required signatures and the serialized
+//public keys of the multisig script s.
+func parseMultisig(s []byte) (int, [][]byte, bool) {
+	m, keys, err := address.ParseMultisigScript(s, nil)
+	if err != nil {
+		return 0, nil, false
+	}
+	pubs := make([][]byte, len(keys))
+	for i, k := range keys {
+		pubs[i] = k.Serialize()
+	}
+	return m, pubs, true
+}
+
+//pushData returns the script which pushes data with the minimal opcode.
+func pushData(data []byte) []byte {
+	var buf bytes.Buffer
+	switch l := len(data); {
+	case l == 0:
+		buf.WriteByte(op0)
+	case l < opPushData1:
+		buf.WriteByte(byte(l))
+	case l <= 0xff:
+		buf.Write([]byte{opPushData1, byte(l)})
+	default:
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], uint16(l))
+		buf.WriteByte(opPushData2)
+		buf.Write(b[:])
+	}
+	buf.Write(data)
+	return buf.Bytes()
+}
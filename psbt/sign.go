@@ -0,0 +1,279 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package psbt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/bitgoin/address"
+	"github.com/bitgoin/address/btcec"
+)
+
+//errors of signing.
+var (
+	ErrMissingUtxo          = errors.New("utxo of the input is missing")
+	ErrMissingRedeemScript  = errors.New("redeem script of the input is missing or mismatched")
+	ErrMissingWitnessScript = errors.New("witness script of the input is missing or mismatched")
+)
+
+//Sign signs the inputs which have BIP32 derivations of keys derived from
+//the master key with the fingerprint of master, and returns the number of
+//signatures added. master must be a private extended key at depth 0.
+func (p *Packet) Sign(master *address.ExtendedKey) (int, error) {
+	if !master.IsPrivate() {
+		return 0, address.ErrNotPrivExtKey
+	}
+	tx, err := p.Tx()
+	if err != nil {
+		return 0, err
+	}
+	fp := master.Fingerprint()
+	n := 0
+	for i, in := range p.Inputs {
+		for _, d := range in.Bip32Derivation {
+			if d.Fingerprint != fp {
+				continue
+			}
+			priv, err := derivePrivKey(master, d.Path)
+			if err != nil {
+				return n, err
+			}
+			if !bytes.Equal(priv.PublicKey.SerializeCompressed(), d.PubKey) {
+				continue
+			}
+			ok, err := p.signInput(tx, i, priv)
+			if err != nil {
+				return n, err
+			}
+			if ok {
+				n++
+			}
+		}
+		for _, d := range in.TaprootBip32Derivation {
+			//signatures of script paths are not supported.
+			if d.Fingerprint != fp || len(d.LeafHashes) != 0 {
+				continue
+			}
+			priv, err := derivePrivKey(master, d.Path)
+			if err != nil {
+				return n, err
+			}
+			if !bytes.Equal(priv.PublicKey.XOnly(), d.XOnlyPubKey) {
+				continue
+			}
+			ok, err := p.signInput(tx, i, priv)
+			if err != nil {
+				return n, err
+			}
+			if ok {
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+func derivePrivKey(master *address.ExtendedKey, path address.DerivationPath) (*address.PrivateKey, error) {
+	k, err := master.DerivePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return k.PrivKey()
+}
+
+//SignWithKey signs all inputs which can be signed by priv, and returns the
+//number of signatures added.
+func (p *Packet) SignWithKey(priv *address.PrivateKey) (int, error) {
+	tx, err := p.Tx()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for i := range p.Inputs {
+		ok, err := p.signInput(tx, i, priv)
+		if err != nil {
+			return n, err
+		}
+		if ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+//signInput adds a signature by priv to the i-th input if the key is used in
+//the input, and returns true if added.
+func (p *Packet) signInput(tx *address.Tx, i int, priv *address.PrivateKey) (bool, error) {
+	in := p.Inputs[i]
+	if in.FinalScriptSig != nil || in.FinalScriptWitness != nil {
+		return false, nil
+	}
+	prev := p.prevOut(i)
+	if prev == nil {
+		return false, ErrMissingUtxo
+	}
+	if isP2TR(prev.PkScript) {
+		return p.signTaproot(tx, i, priv)
+	}
+
+	hashType := address.SigHashAll
+	if in.SighashType != nil {
+		hashType = *in.SighashType
+	}
+	//signing SigHashSingle without the output signs the hash 1, which
+	//can be reused for any transaction.
+	if hashType&^address.SigHashAnyOneCanPay == address.SigHashSingle && i >= len(tx.TxOut) {
+		return false, address.ErrNoSingleOutput
+	}
+	script := prev.PkScript
+	if isP2SH(script) {
+		if in.RedeemScript == nil ||
+			!bytes.Equal(script[2:22], address.AddressBytes(in.RedeemScript)) {
+			return false, ErrMissingRedeemScript
+		}
+		script = in.RedeemScript
+	}
+
+	var pub, hash []byte
+	var err error
+	switch {
+	case isP2WPKH(script):
+		pub = priv.PublicKey.SerializeCompressed()
+		if !bytes.Equal(script[2:], address.AddressBytes(pub)) {
+			return false, nil
+		}
+		hash, err = tx.WitnessV0SigHash(i, p2pkhScript(script[2:]), prev.Value, hashType)
+	case isP2WSH(script):
+		h := sha256.Sum256(in.WitnessScript)
+		if in.WitnessScript == nil || !bytes.Equal(script[2:], h[:]) {
+			return false, ErrMissingWitnessScript
+		}
+		pub = priv.PublicKey.SerializeCompressed()
+		if !hasMultisigKey(in.WitnessScript, pub) {
+			return false, nil
+		}
+		hash, err = tx.WitnessV0SigHash(i, in.WitnessScript, prev.Value, hashType)
+	case isWitnessProgram(script):
+		return false, nil
+	default:
+		pub = priv.PublicKey.Serialize()
+		if isP2PKH(script) {
+			if !bytes.Equal(script[3:23], address.AddressBytes(pub)) {
+				return false, nil
+			}
+		} else if !hasMultisigKey(script, pub) {
+			return false, nil
+		}
+		if in.NonWitnessUtxo == nil {
+			return false, ErrMissingUtxo
+		}
+		hash = tx.LegacySigHash(i, script, hashType)
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, s := range in.PartialSigs {
+		if bytes.Equal(s.PubKey, pub) {
+			return false, nil
+		}
+	}
+	sig, err := priv.Sign(hash)
+	if err != nil {
+		return false, err
+	}
+	in.PartialSigs = append(in.PartialSigs, &PartialSig{
+		PubKey:    pub,
+		Signature: append(sig, byte(hashType)),
+	})
+	return true, nil
+}
+
+//hasMultisigKey returns true if script is a multisig script including pub.
+func hasMultisigKey(script, pub []byte) bool {
+	_, pubs, ok := parseMultisig(script)
+	if !ok {
+		return false
+	}
+	for _, pk := range pubs {
+		if bytes.Equal(pk, pub) {
+			return true
+		}
+	}
+	return false
+}
+
+//signTaproot adds the key path signature by priv, which is the internal key,
+//to the i-th input.
+func (p *Packet) signTaproot(tx *address.Tx, i int, priv *address.PrivateKey) (bool, error) {
+	in := p.Inputs[i]
+	if in.TaprootKeySig != nil {
+		return false, nil
+	}
+	if in.TaprootInternalKey != nil &&
+		!bytes.Equal(in.TaprootInternalKey, priv.PublicKey.XOnly()) {
+		return false, nil
+	}
+	tweaked, err := priv.TaprootTweak(in.TaprootMerkleRoot)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(tweaked.PublicKey.XOnly(), p.prevOut(i).PkScript[2:]) {
+		return false, nil
+	}
+	hashType := address.SigHashDefault
+	if in.SighashType != nil {
+		hashType = *in.SighashType
+	}
+	prevOuts := make([]*address.TxOut, len(p.Inputs))
+	for j := range p.Inputs {
+		if prevOuts[j] = p.prevOut(j); prevOuts[j] == nil {
+			return false, ErrMissingUtxo
+		}
+	}
+	hash, err := tx.TaprootSigHash(i, prevOuts, hashType, nil)
+	if err != nil {
+		return false, err
+	}
+	aux := make([]byte, 32)
+	if _, err := rand.Read(aux); err != nil {
+		return false, err
+	}
+	sig, err := btcec.SignSchnorr(tweaked.PrivateKey, hash, aux)
+	if err != nil {
+		return false, err
+	}
+	if hashType != address.SigHashDefault {
+		sig = append(sig, byte(hashType))
+	}
+	in.TaprootKeySig = sig
+	return true, nil
+}
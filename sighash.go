@@ -0,0 +1,292 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/bitgoin/address/btcec"
+)
+
+//SigHashType represents which parts of a transaction are signed.
+type SigHashType uint32
+
+//sighash types.
+const (
+	//SigHashDefault is the taproot sighash type which signs the same as
+	//SigHashAll but omits the type byte in the signature.
+	SigHashDefault SigHashType = 0x00
+	//SigHashAll signs all inputs and outputs.
+	SigHashAll SigHashType = 0x01
+	//SigHashNone signs all inputs and no outputs.
+	SigHashNone SigHashType = 0x02
+	//SigHashSingle signs all inputs and the output at the same index.
+	SigHashSingle SigHashType = 0x03
+	//SigHashAnyOneCanPay is a flag which signs only the input to be signed.
+	SigHashAnyOneCanPay SigHashType = 0x80
+
+	sigHashMask = 0x1f
+)
+
+var (
+	//ErrInvalidSigHashType is returned when the sighash type is not
+	//allowed in taproot.
+	ErrInvalidSigHashType = errors.New("invalid sighash type")
+
+	//ErrInputIndex is returned when the input index is out of the range.
+	ErrInputIndex = errors.New("input index out of range")
+
+	//ErrNoSingleOutput is returned when SigHashSingle is used in taproot
+	//without the output at the same index as the input.
+	ErrNoSingleOutput = errors.New("no output corresponding to the input for SigHashSingle")
+)
+
+//opCodeSeparator is OP_CODESEPARATOR, which is removed from script codes
+//of the legacy sighash.
+const opCodeSeparator = 0xab
+
+//removeCodeSeparators returns script without OP_CODESEPARATOR. Malformed
+//pushes at the end of script are kept as they are.
+func removeCodeSeparators(script []byte) []byte {
+	var s []byte
+	for i := 0; i < len(script); {
+		op := script[i]
+		l := 1
+		switch {
+		case op <= 0x4b:
+			l += int(op)
+		case op == 0x4c && i+1 < len(script):
+			l += 1 + int(script[i+1])
+		case op == 0x4d && i+2 < len(script):
+			l += 2 + int(binary.LittleEndian.Uint16(script[i+1:]))
+		case op == 0x4e && i+4 < len(script):
+			l += 4 + int(binary.LittleEndian.Uint32(script[i+1:]))
+		}
+		if i+l > len(script) || l < 0 {
+			return append(s, script[i:]...)
+		}
+		if op != opCodeSeparator {
+			s = append(s, script[i:i+l]...)
+		}
+		i += l
+	}
+	return s
+}
+
+//LegacySigHash returns the signature hash of the idx-th input of a pre-segwit
+//transaction, where subScript is the script of the output being spent (or
+//the redeem script of P2SH).
+//As Bitcoin Core does, it returns 1 (little endian uint256) if idx is out of
+//range or SigHashSingle is used without the corresponding output.
+func (tx *Tx) LegacySigHash(idx int, subScript []byte, hashType SigHashType) []byte {
+	one := make([]byte, 32)
+	one[0] = 0x01
+	base := hashType & sigHashMask
+	if idx < 0 || idx >= len(tx.TxIn) || (base == SigHashSingle && idx >= len(tx.TxOut)) {
+		return one
+	}
+
+	var w bytes.Buffer
+	writeUint32(&w, uint32(tx.Version))
+	ins := tx.TxIn
+	if hashType&SigHashAnyOneCanPay != 0 {
+		ins = tx.TxIn[idx : idx+1]
+	}
+	writeVarInt(&w, uint64(len(ins)))
+	for _, in := range ins {
+		w.Write(in.PreviousOutPoint.Hash)
+		writeUint32(&w, in.PreviousOutPoint.Index)
+		seq := in.Sequence
+		if in == tx.TxIn[idx] {
+			writeVarBytes(&w, removeCodeSeparators(subScript))
+		} else {
+			writeVarInt(&w, 0)
+			if base == SigHashNone || base == SigHashSingle {
+				seq = 0
+			}
+		}
+		writeUint32(&w, seq)
+	}
+	switch base {
+	case SigHashNone:
+		writeVarInt(&w, 0)
+	case SigHashSingle:
+		writeVarInt(&w, uint64(idx+1))
+		for i := 0; i < idx; i++ {
+			(&TxOut{Value: -1}).serialize(&w)
+		}
+		tx.TxOut[idx].serialize(&w)
+	default:
+		writeVarInt(&w, uint64(len(tx.TxOut)))
+		for _, out := range tx.TxOut {
+			out.serialize(&w)
+		}
+	}
+	writeUint32(&w, tx.LockTime)
+	writeUint32(&w, uint32(hashType))
+	return doubleSHA256(w.Bytes())
+}
+
+//WitnessV0SigHash returns the BIP143 signature hash of the idx-th input
+//spending amount, where scriptCode is the P2PKH script of the key hash for
+//P2WPKH or the witness script for P2WSH.
+func (tx *Tx) WitnessV0SigHash(idx int, scriptCode []byte, amount int64, hashType SigHashType) ([]byte, error) {
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, ErrInputIndex
+	}
+	base := hashType & sigHashMask
+	anyoneCanPay := hashType&SigHashAnyOneCanPay != 0
+	hashPrevouts := make([]byte, 32)
+	hashSequence := make([]byte, 32)
+	hashOutputs := make([]byte, 32)
+	if !anyoneCanPay {
+		hashPrevouts = doubleSHA256(tx.serializePrevouts())
+		if base != SigHashSingle && base != SigHashNone {
+			hashSequence = doubleSHA256(tx.serializeSequences())
+		}
+	}
+	switch {
+	case base != SigHashSingle && base != SigHashNone:
+		hashOutputs = doubleSHA256(tx.serializeOutputs())
+	case base == SigHashSingle && idx < len(tx.TxOut):
+		hashOutputs = doubleSHA256(tx.TxOut[idx].Serialize())
+	}
+
+	var w bytes.Buffer
+	in := tx.TxIn[idx]
+	writeUint32(&w, uint32(tx.Version))
+	w.Write(hashPrevouts)
+	w.Write(hashSequence)
+	w.Write(in.PreviousOutPoint.Hash)
+	writeUint32(&w, in.PreviousOutPoint.Index)
+	writeVarBytes(&w, scriptCode)
+	writeUint64(&w, uint64(amount))
+	writeUint32(&w, in.Sequence)
+	w.Write(hashOutputs)
+	writeUint32(&w, tx.LockTime)
+	writeUint32(&w, uint32(hashType))
+	return doubleSHA256(w.Bytes()), nil
+}
+
+//TaprootSigHash returns the BIP341 signature hash of the idx-th input.
+//prevOuts are the outputs spent by all inputs of the tx.
+//leafHash is the tapleaf hash of the script for script path spends, or nil
+//for key path spends. Annexes and OP_CODESEPARATOR are not supported.
+func (tx *Tx) TaprootSigHash(idx int, prevOuts []*TxOut, hashType SigHashType, leafHash []byte) ([]byte, error) {
+	if idx < 0 || idx >= len(tx.TxIn) || len(prevOuts) != len(tx.TxIn) {
+		return nil, ErrInputIndex
+	}
+	switch hashType {
+	case SigHashDefault, SigHashAll, SigHashNone, SigHashSingle,
+		SigHashAll | SigHashAnyOneCanPay, SigHashNone | SigHashAnyOneCanPay,
+		SigHashSingle | SigHashAnyOneCanPay:
+	default:
+		return nil, ErrInvalidSigHashType
+	}
+	base := hashType & sigHashMask
+	anyoneCanPay := hashType&SigHashAnyOneCanPay != 0
+
+	var w bytes.Buffer
+	w.WriteByte(0x00) //epoch
+	w.WriteByte(byte(hashType))
+	writeUint32(&w, uint32(tx.Version))
+	writeUint32(&w, tx.LockTime)
+	if !anyoneCanPay {
+		var amounts, scripts bytes.Buffer
+		for _, out := range prevOuts {
+			writeUint64(&amounts, uint64(out.Value))
+			writeVarBytes(&scripts, out.PkScript)
+		}
+		for _, b := range [][]byte{tx.serializePrevouts(), amounts.Bytes(),
+			scripts.Bytes(), tx.serializeSequences()} {
+			h := sha256.Sum256(b)
+			w.Write(h[:])
+		}
+	}
+	if base != SigHashNone && base != SigHashSingle {
+		h := sha256.Sum256(tx.serializeOutputs())
+		w.Write(h[:])
+	}
+	var spendType byte
+	if leafHash != nil {
+		spendType = 2
+	}
+	w.WriteByte(spendType)
+	if anyoneCanPay {
+		in := tx.TxIn[idx]
+		w.Write(in.PreviousOutPoint.Hash)
+		writeUint32(&w, in.PreviousOutPoint.Index)
+		writeUint64(&w, uint64(prevOuts[idx].Value))
+		writeVarBytes(&w, prevOuts[idx].PkScript)
+		writeUint32(&w, in.Sequence)
+	} else {
+		writeUint32(&w, uint32(idx))
+	}
+	if base == SigHashSingle {
+		if idx >= len(tx.TxOut) {
+			return nil, ErrNoSingleOutput
+		}
+		h := sha256.Sum256(tx.TxOut[idx].Serialize())
+		w.Write(h[:])
+	}
+	if leafHash != nil {
+		w.Write(leafHash)
+		w.WriteByte(0x00) //key version
+		writeUint32(&w, 0xffffffff)
+	}
+	return btcec.TaggedHash("TapSighash", w.Bytes()), nil
+}
+
+func (tx *Tx) serializePrevouts() []byte {
+	var w bytes.Buffer
+	for _, in := range tx.TxIn {
+		w.Write(in.PreviousOutPoint.Hash)
+		writeUint32(&w, in.PreviousOutPoint.Index)
+	}
+	return w.Bytes()
+}
+
+func (tx *Tx) serializeSequences() []byte {
+	var w bytes.Buffer
+	for _, in := range tx.TxIn {
+		writeUint32(&w, in.Sequence)
+	}
+	return w.Bytes()
+}
+
+func (tx *Tx) serializeOutputs() []byte {
+	var w bytes.Buffer
+	for _, out := range tx.TxOut {
+		out.serialize(&w)
+	}
+	return w.Bytes()
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+//reverse returns the byte reversed copy of b, e.g. to convert txids.
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+//TestLegacySigHash tests with sighash.json of Bitcoin Core.
+func TestLegacySigHash(t *testing.T) {
+	file, err := os.ReadFile("testdata/sighash.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests [][]interface{}
+	if err := json.Unmarshal(file, &tests); err != nil {
+		t.Fatal(err)
+	}
+	//the first line is a comment.
+	for i, test := range tests[1:] {
+		raw := mustDecodeHex(t, test[0].(string))
+		tx, err := ParseTx(raw)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !bytes.Equal(tx.Serialize(), raw) {
+			t.Errorf("#%d: tx is not reserialized", i)
+		}
+		script := mustDecodeHex(t, test[1].(string))
+		hashType := SigHashType(uint32(int32(test[3].(float64))))
+		hash := tx.LegacySigHash(int(test[2].(float64)), script, hashType)
+		if h := hex.EncodeToString(reverse(hash)); h != test[4].(string) {
+			t.Errorf("#%d: sighash %s, expected %s", i, h, test[4])
+		}
+	}
+}
+
+//TestWitnessV0SigHash tests with the examples in BIP143.
+func TestWitnessV0SigHash(t *testing.T) {
+	const sixOfSix = "56210307b8ae49ac90a048e9b53357a2354b3334e9c8bee813ecb98e99a7e07e8c3ba32103b28f0c28bfab54554ae8c658ac5c3e0ce6e79ad336331f78c428dd43eea8449b21034b8113d703413d57761b8b9781957b8c0ac1dfe69f492580ca4195f50376ba4a21033400f6afecb833092a9a21cfdf1ed1376e58c5d1f47de74683123987e967a8f42103a6d48b1131e94ba04d9737d61acdaa1322008af9602b3b14862c07a1789aac162102d8b661b0b3302ee2f162b09e07a55ad5dfbe673a9f01d9f0c19617681024306b56ae"
+	const sixOfSixTx = "010000000136641869ca081e70f394c6948e8af409e18b619df2ed74aa106c1ca29787b96e0100000000ffffffff0200e9a435000000001976a914389ffce9cd9ae88dcc0631e88a821ffdbe9bfe2688acc0832f05000000001976a9147480a33f950689af511e6e84c138dbbd3c3ee41588ac00000000"
+	for i, test := range []struct {
+		tx         string
+		idx        int
+		scriptCode string
+		amount     int64
+		hashType   SigHashType
+		sighash    string
+	}{
+		{
+			//native P2WPKH
+			tx:         "0100000002fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f0000000000eeffffffef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac11000000",
+			idx:        1,
+			scriptCode: "76a9141d0f172a0ecb48aee1be1f2687d2963ae33f71a188ac",
+			amount:     600000000,
+			hashType:   SigHashAll,
+			sighash:    "c37af31116d1b27caf68aae9e3ac82f1477929014d5b917657d0eb49478cb670",
+		},
+		{
+			//P2SH-P2WPKH
+			tx:         "0100000001db6b1b20aa0fd7b23880be2ecbd4a98130974cf4748fb66092ac4d3ceb1a54770100000000feffffff02b8b4eb0b000000001976a914a457b684d7f0d539a46a45bbc043f35b59d0d96388ac0008af2f000000001976a914fd270b1ee6abcaea97fea7ad0402e8bd8ad6d77c88ac92040000",
+			idx:        0,
+			scriptCode: "76a91479091972186c449eb1ded22b78e40d009bdf008988ac",
+			amount:     1000000000,
+			hashType:   SigHashAll,
+			sighash:    "64f3b0f4dd2bb3aa1ce8566d220cc74dda9df97d8490cc81d89d735c92e59fb6",
+		},
+		{sixOfSixTx, 0, sixOfSix, 987654321, SigHashAll,
+			"185c0be5263dce5b4bb50a047973c1b6272bfbd0103a89444597dc40b248ee7c"},
+		{sixOfSixTx, 0, sixOfSix, 987654321, SigHashNone,
+			"e9733bc60ea13c95c6527066bb975a2ff29a925e80aa14c213f686cbae5d2f36"},
+		{sixOfSixTx, 0, sixOfSix, 987654321, SigHashSingle,
+			"1e1f1c303dc025bd664acb72e583e933fae4cff9148bf78c157d1e8f78530aea"},
+		{sixOfSixTx, 0, sixOfSix, 987654321, SigHashAll | SigHashAnyOneCanPay,
+			"2a67f03e63a6a422125878b40b82da593be8d4efaafe88ee528af6e5a9955c6e"},
+		{sixOfSixTx, 0, sixOfSix, 987654321, SigHashNone | SigHashAnyOneCanPay,
+			"781ba15f3779d5542ce8ecb5c18716733a5ee42a6f51488ec96154934e2c890a"},
+		{sixOfSixTx, 0, sixOfSix, 987654321, SigHashSingle | SigHashAnyOneCanPay,
+			"511e8e52ed574121fc1b654970395502128263f62662e076dc6baf05c2e6a99b"},
+	} {
+		tx, err := ParseTx(mustDecodeHex(t, test.tx))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash, err := tx.WitnessV0SigHash(test.idx, mustDecodeHex(t, test.scriptCode), test.amount, test.hashType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := hex.EncodeToString(hash); h != test.sighash {
+			t.Errorf("#%d: sighash %s, expected %s", i, h, test.sighash)
+		}
+	}
+}
+
+//TestTaprootSigHash tests with keyPathSpending of the wallet test vectors
+//in BIP341.
+func TestTaprootSigHash(t *testing.T) {
+	tx, err := ParseTx(mustDecodeHex(t, "02000000097de20cbff686da83a54981d2b9bab3586f4ca7e48f57f5b55963115f3b334e9c010000000000000000d7b7cab57b1393ace2d064f4d4a2cb8af6def61273e127517d44759b6dafdd990000000000fffffffff8e1f583384333689228c5d28eac13366be082dc57441760d957275419a418420000000000fffffffff0689180aa63b30cb162a73c6d2a38b7eeda2a83ece74310fda0843ad604853b0100000000feffffffaa5202bdf6d8ccd2ee0f0202afbbb7461d9264a25e5bfd3c5a52ee1239e0ba6c0000000000feffffff956149bdc66faa968eb2be2d2faa29718acbfe3941215893a2a3446d32acd050000000000000000000e664b9773b88c09c32cb70a2a3e4da0ced63b7ba3b22f848531bbb1d5d5f4c94010000000000000000e9aa6b8e6c9de67619e6a3924ae25696bb7b694bb677a632a74ef7eadfd4eabf0000000000ffffffffa778eb6a263dc090464cd125c466b5a99667720b1c110468831d058aa1b82af10100000000ffffffff0200ca9a3b000000001976a91406afd46bcdfd22ef94ac122aa11f241244a37ecc88ac807840cb0000000020ac9a87f5594be208f8532db38cff670c450ed2fea8fcdefcc9a663f78bab962b0065cd1d"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prevOuts []*TxOut
+	for _, u := range []struct {
+		script string
+		amount int64
+	}{
+		{"512053a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343", 420000000},
+		{"5120147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3", 462000000},
+		{"76a914751e76e8199196d454941c45d1b3a323f1433bd688ac", 294000000},
+		{"5120e4d810fd50586274face62b8a807eb9719cef49c04177cc6b76a9a4251d5450e", 504000000},
+		{"512091b64d5324723a985170e4dc5a0f84c041804f2cd12660fa5dec09fc21783605", 630000000},
+		{"00147dd65592d0ab2fe0d0257d571abf032cd9db93dc", 378000000},
+		{"512075169f4001aa68f15bbed28b218df1d0a62cbbcf1188c6665110c293c907b831", 672000000},
+		{"5120712447206d7a5238acc7ff53fbe94a3b64539ad291c7cdbc490b7577e4b17df5", 546000000},
+		{"512077e30a5522dd9f894c3f8b8bd4c4b2cf82ca7da8a3ea6a239655c39c050ab220", 588000000},
+	} {
+		prevOuts = append(prevOuts, &TxOut{Value: u.amount, PkScript: mustDecodeHex(t, u.script)})
+	}
+	for _, test := range []struct {
+		idx      int
+		hashType SigHashType
+		sighash  string
+	}{
+		{0, SigHashSingle, "2514a6272f85cfa0f45eb907fcb0d121b808ed37c6ea160a5a9046ed5526d555"},
+		{1, SigHashSingle | SigHashAnyOneCanPay, "325a644af47e8a5a2591cda0ab0723978537318f10e6a63d4eed783b96a71a4d"},
+		{3, SigHashAll, "bf013ea93474aa67815b1b6cc441d23b64fa310911d991e713cd34c7f5d46669"},
+		{4, SigHashDefault, "4f900a0bae3f1446fd48490c2958b5a023228f01661cda3496a11da502a7f7ef"},
+		{6, SigHashNone, "15f25c298eb5cdc7eb1d638dd2d45c97c4c59dcaec6679cfc16ad84f30876b85"},
+		{7, SigHashNone | SigHashAnyOneCanPay, "cd292de50313804dabe4685e83f923d2969577191a3e1d2882220dca88cbeb10"},
+		{8, SigHashAll | SigHashAnyOneCanPay, "cccb739eca6c13a8a89e6e5cd317ffe55669bbda23f2fd37b0f18755e008edd2"},
+	} {
+		hash, err := tx.TaprootSigHash(test.idx, prevOuts, test.hashType, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := hex.EncodeToString(hash); h != test.sighash {
+			t.Errorf("input %d: sighash %s, expected %s", test.idx, h, test.sighash)
+		}
+	}
+	if _, err := tx.TaprootSigHash(0, prevOuts, 0x04, nil); err != ErrInvalidSigHashType {
+		t.Errorf("invalid sighash type must be rejected: %v", err)
+	}
+}
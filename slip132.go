@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+// References:
+//   [SLIP132]: Registered HD version bytes for BIP-0032
+//   https://github.com/satoshilabs/slips/blob/master/slip-0132.md
+
+import (
+	"bytes"
+	"errors"
+)
+
+//ScriptType is the type of scripts the keys under an extended key are used
+//for, implied by the version bytes.
+type ScriptType int
+
+//Script types.
+const (
+	//ScriptP2PKH is for P2PKH or legacy P2SH multisig.
+	ScriptP2PKH ScriptType = iota
+	//ScriptP2SHP2WPKH is for P2WPKH nested in P2SH.
+	ScriptP2SHP2WPKH
+	//ScriptP2WPKH is for native segwit P2WPKH.
+	ScriptP2WPKH
+	//ScriptP2SHP2WSH is for multisig P2WSH nested in P2SH.
+	ScriptP2SHP2WSH
+	//ScriptP2WSH is for native segwit multisig P2WSH.
+	ScriptP2WSH
+)
+
+var scriptTypeNames = map[ScriptType]string{
+	ScriptP2PKH:      "p2pkh",
+	ScriptP2SHP2WPKH: "p2sh-p2wpkh",
+	ScriptP2WPKH:     "p2wpkh",
+	ScriptP2SHP2WSH:  "p2sh-p2wsh",
+	ScriptP2WSH:      "p2wsh",
+}
+
+//String returns the name of the script type.
+func (s ScriptType) String() string {
+	if n, ok := scriptTypeNames[s]; ok {
+		return n
+	}
+	return "unknown"
+}
+
+var (
+	//ErrUnknownHDVersion describes an error in which the version bytes of a
+	//serialized extended key are not registered for the network.
+	ErrUnknownHDVersion = errors.New("unknown extended key version")
+
+	//ErrHDVersionMismatch describes an error in which the version bytes
+	//are for a private key but the key data is public or vice versa.
+	ErrHDVersionMismatch = errors.New("extended key version does not " +
+		"match the key data")
+
+	//ErrHDVersionNetwork describes an error in which the version bytes are
+	//for another network than the extended key.
+	ErrHDVersionNetwork = errors.New("extended key version is not for the " +
+		"network of the key")
+)
+
+//HDVersion is a pair of version bytes for serialized extended keys.
+type HDVersion struct {
+	//Name is the prefix of the public key, such as "zpub".
+	Name string
+	//PrivateID is the version bytes of extended private keys.
+	PrivateID []byte
+	//PublicID is the version bytes of extended public keys.
+	PublicID []byte
+	//Script is the script type of the keys.
+	Script ScriptType
+	//Param is the network of the keys.
+	Param *Params
+}
+
+//hdVersions is the registry of version bytes.
+var hdVersions = []*HDVersion{
+	{"xpub", []byte{0x04, 0x88, 0xad, 0xe4}, []byte{0x04, 0x88, 0xb2, 0x1e}, ScriptP2PKH, BitcoinMain},
+	{"ypub", []byte{0x04, 0x9d, 0x78, 0x78}, []byte{0x04, 0x9d, 0x7c, 0xb2}, ScriptP2SHP2WPKH, BitcoinMain},
+	{"Ypub", []byte{0x02, 0x95, 0xb0, 0x05}, []byte{0x02, 0x95, 0xb4, 0x3f}, ScriptP2SHP2WSH, BitcoinMain},
+	{"zpub", []byte{0x04, 0xb2, 0x43, 0x0c}, []byte{0x04, 0xb2, 0x47, 0x46}, ScriptP2WPKH, BitcoinMain},
+	{"Zpub", []byte{0x02, 0xaa, 0x7a, 0x99}, []byte{0x02, 0xaa, 0x7e, 0xd3}, ScriptP2WSH, BitcoinMain},
+	{"tpub", []byte{0x04, 0x35, 0x83, 0x94}, []byte{0x04, 0x35, 0x87, 0xcf}, ScriptP2PKH, BitcoinTest},
+	{"upub", []byte{0x04, 0x4a, 0x4e, 0x28}, []byte{0x04, 0x4a, 0x52, 0x62}, ScriptP2SHP2WPKH, BitcoinTest},
+	{"Upub", []byte{0x02, 0x42, 0x85, 0xb5}, []byte{0x02, 0x42, 0x89, 0xef}, ScriptP2SHP2WSH, BitcoinTest},
+	{"vpub", []byte{0x04, 0x5f, 0x18, 0xbc}, []byte{0x04, 0x5f, 0x1c, 0xf6}, ScriptP2WPKH, BitcoinTest},
+	{"Vpub", []byte{0x02, 0x57, 0x50, 0x48}, []byte{0x02, 0x57, 0x54, 0x83}, ScriptP2WSH, BitcoinTest},
+	{"Ltub", []byte{0x01, 0x9d, 0x9c, 0xfe}, []byte{0x01, 0x9d, 0xa4, 0x62}, ScriptP2PKH, LitecoinMain},
+	{"Mtub", []byte{0x01, 0xb2, 0x67, 0x92}, []byte{0x01, 0xb2, 0x6e, 0xf6}, ScriptP2SHP2WPKH, LitecoinMain},
+	{"dgub", []byte{0x02, 0xfa, 0xc3, 0x98}, []byte{0x02, 0xfa, 0xca, 0xfd}, ScriptP2PKH, DogecoinMain},
+}
+
+//RegisterHDVersion adds version bytes to the registry so that
+//NewKeyFromString accepts them.
+func RegisterHDVersion(v *HDVersion) {
+	hdVersions = append(hdVersions, v)
+}
+
+//HDVersions returns the registered version bytes for the network.
+func HDVersions(param *Params) []*HDVersion {
+	var vs []*HDVersion
+	for _, v := range hdVersions {
+		if v.Param == param {
+			vs = append(vs, v)
+		}
+	}
+	return vs
+}
+
+//LookupHDVersion returns the registered version of the network param and
+//the script type.
+func LookupHDVersion(param *Params, script ScriptType) (*HDVersion, error) {
+	for _, v := range hdVersions {
+		if v.Param == param && v.Script == script {
+			return v, nil
+		}
+	}
+	return nil, ErrUnknownHDVersion
+}
+
+//defaultHDVersion returns the version of Params.HDPrivateKeyID and
+//Params.HDPublicKeyID.
+func defaultHDVersion(param *Params) *HDVersion {
+	for _, v := range hdVersions {
+		if v.Param == param && bytes.Equal(v.PrivateID, param.HDPrivateKeyID) &&
+			bytes.Equal(v.PublicID, param.HDPublicKeyID) {
+			return v
+		}
+	}
+	return &HDVersion{
+		PrivateID: param.HDPrivateKeyID,
+		PublicID:  param.HDPublicKeyID,
+		Script:    ScriptP2PKH,
+		Param:     param,
+	}
+}
+
+//findHDVersion returns the version whose private or public ID is id for the
+//network. isPrivate tells which ID matched.
+func findHDVersion(id []byte, param *Params) (v *HDVersion, isPrivate bool, err error) {
+	if bytes.Equal(id, param.HDPrivateKeyID) {
+		return defaultHDVersion(param), true, nil
+	}
+	if bytes.Equal(id, param.HDPublicKeyID) {
+		return defaultHDVersion(param), false, nil
+	}
+	for _, v := range HDVersions(param) {
+		if bytes.Equal(id, v.PrivateID) {
+			return v, true, nil
+		}
+		if bytes.Equal(id, v.PublicID) {
+			return v, false, nil
+		}
+	}
+	return nil, false, ErrUnknownHDVersion
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"testing"
+
+	"github.com/bitgoin/address/base58"
+)
+
+//test vectors from BIP84.
+func TestSLIP132(t *testing.T) {
+	const (
+		zprv = "zprvAdG4iTXWBoARxkkzNpNh8r6Qag3irQB8PzEMkAFeTRXxHpbF9z4QgEvBRmfvqWvGp42t42nvgGpNgYSJA9iefm1yYNZKEm7z6qUWCroSQnE"
+		zpub = "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
+	)
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	master, err := NewMaster(NewSeed(mnemonic, ""), BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc, err := NewAccount(master, Purpose84, BitcoinMain.CoinType, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := LookupHDVersion(BitcoinMain, ScriptP2WPKH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := acc.Key().WithVersion(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.String() != zprv {
+		t.Error("invalid zprv", k)
+	}
+	if acc.Key().Version().Name != "xpub" {
+		t.Error("version of the original key must not be changed")
+	}
+	tv, err := LookupHDVersion(BitcoinTest, ScriptP2WPKH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := acc.Key().WithVersion(tv); err != ErrHDVersionNetwork {
+		t.Error("testnet version must not be set to a mainnet key", err)
+	}
+	pub, err := k.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.String() != zpub {
+		t.Error("invalid zpub", pub)
+	}
+
+	for _, s := range []string{zprv, zpub} {
+		k, err := NewKeyFromString(s, BitcoinMain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.Version().Script != ScriptP2WPKH || k.Version().Param != BitcoinMain {
+			t.Error(s, "invalid version", k.Version().Name)
+		}
+		if k.String() != s {
+			t.Error(s, "reserialized to", k)
+		}
+		if k.IsPrivate() != (s == zprv) {
+			t.Error(s, "invalid private flag")
+		}
+		child, err := k.DerivePath(DerivationPath{0, 0})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if child.Version() != k.Version() {
+			t.Error("child must inherit the version")
+		}
+		p, err := child.PubKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if adr, _ := p.WitnessAddress(); adr != "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu" {
+			t.Error("invalid address", adr)
+		}
+	}
+
+	if _, err := NewKeyFromString(zpub, BitcoinTest); err != ErrUnknownHDVersion {
+		t.Error("zpub must not be accepted for testnet", err)
+	}
+
+	//version bytes of zprv with public key data.
+	payload, err := base58.Decode(zpub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(payload, v.PrivateID)
+	if _, err := NewKeyFromString(base58.Encode(payload), BitcoinMain); err != ErrHDVersionMismatch {
+		t.Error("version mismatch must be detected", err)
+	}
+}
@@ -0,0 +1,602 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+// References:
+//   [SLIP39]: Shamir's Secret-Sharing for Mnemonic Codes
+//   https://github.com/satoshilabs/slips/blob/master/slip-0039.md
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	slip39RadixBits        = 10
+	slip39IDBits           = 15
+	slip39IterationExpBits = 4
+	slip39ChecksumWords    = 3
+	slip39DigestLen        = 4
+	slip39MetadataWords    = 7
+	slip39MinSecretLen     = 16
+	slip39MinWords         = slip39MetadataWords + (slip39MinSecretLen*8+slip39RadixBits-1)/slip39RadixBits
+	slip39BaseIterations   = 10000
+	slip39Rounds           = 4
+	slip39SecretIndex      = 255
+	slip39DigestIndex      = 254
+
+	//SLIP39MaxShares is the maximum number of groups and of member shares
+	//in a group.
+	SLIP39MaxShares = 16
+)
+
+var (
+	//ErrSLIP39Length is returned when a mnemonic is too short or the length
+	//of its share value is invalid.
+	ErrSLIP39Length = errors.New("invalid SLIP-39 mnemonic length")
+	//ErrSLIP39Checksum is returned when the RS1024 checksum of a mnemonic
+	//doesn't match.
+	ErrSLIP39Checksum = errors.New("invalid SLIP-39 mnemonic checksum")
+	//ErrSLIP39Padding is returned when the padding bits of a share value are
+	//not zero.
+	ErrSLIP39Padding = errors.New("invalid SLIP-39 mnemonic padding")
+	//ErrSLIP39Params is returned when the thresholds, the numbers of shares
+	//or the iteration exponent are out of range.
+	ErrSLIP39Params = errors.New("invalid SLIP-39 group parameters")
+	//ErrSLIP39Mismatch is returned when the shares to combine are not of the
+	//same secret, e.g. their identifiers or thresholds differ.
+	ErrSLIP39Mismatch = errors.New("SLIP-39 shares do not belong to the " +
+		"same secret")
+	//ErrSLIP39Digest is returned when the recovered secret doesn't match its
+	//digest, i.e. some shares are wrong.
+	ErrSLIP39Digest = errors.New("invalid digest of the SLIP-39 shared secret")
+	//ErrSLIP39SecretLen is returned when the master secret is shorter than
+	//16 bytes or its length is odd.
+	ErrSLIP39SecretLen = errors.New("SLIP-39 master secret must be at least " +
+		"16 bytes of even length")
+	//ErrSLIP39Passphrase is returned when the passphrase contains other than
+	//printable ASCII characters.
+	ErrSLIP39Passphrase = errors.New("SLIP-39 passphrase must be printable " +
+		"ASCII")
+	//ErrSLIP39NoShares is returned when no mnemonic is given to combine.
+	ErrSLIP39NoShares = errors.New("no SLIP-39 mnemonics")
+)
+
+//SLIP39ThresholdError is returned by CombineSLIP39Mnemonics when the number
+//of groups or of member shares in a group doesn't match the threshold.
+type SLIP39ThresholdError struct {
+	//Group is the index of the group with the wrong number of member
+	//shares, or -1 if the number of groups is wrong.
+	Group int
+	//Threshold is the number of groups or member shares needed.
+	Threshold int
+	//Given is the number of groups or member shares given.
+	Given int
+}
+
+func (e *SLIP39ThresholdError) Error() string {
+	if e.Group < 0 {
+		return fmt.Sprintf("%d SLIP-39 groups are needed but %d are given",
+			e.Threshold, e.Given)
+	}
+	return fmt.Sprintf("%d SLIP-39 shares of group %d are needed but %d are given",
+		e.Threshold, e.Group, e.Given)
+}
+
+//slip39Index maps a word to its index in the wordlist.
+var slip39Index = func() map[string]int {
+	m := make(map[string]int, len(slip39Words))
+	for i, w := range slip39Words {
+		m[w] = i
+	}
+	return m
+}()
+
+//gf256Exp and gf256Log are the exponent and logarithm tables of GF(256)
+//with the polynomial x^8+x^4+x^3+x+1 and the generator x+1.
+var gf256Exp, gf256Log = func() (exp [255]byte, log [256]int) {
+	p := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(p)
+		log[p] = i
+		p ^= p << 1
+		if p&0x100 != 0 {
+			p ^= 0x11b
+		}
+	}
+	return
+}()
+
+//rs1024Polymod returns the RS1024 checksum polymod of values.
+func rs1024Polymod(values []int) uint32 {
+	gen := [10]uint32{
+		0xe0e040, 0x1c1c080, 0x3838100, 0x7070200, 0xe0e0009,
+		0x1c0c2412, 0x38086c24, 0x3090fc48, 0x21b1f890, 0x3f3f120,
+	}
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xfffff)<<10 ^ uint32(v)
+		for i := uint(0); i < 10; i++ {
+			if (b>>i)&1 != 0 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+//slip39Customization returns the customization string of the checksum as
+//values.
+func slip39Customization(extendable bool) []int {
+	s := "shamir"
+	if extendable {
+		s = "shamir_extendable"
+	}
+	values := make([]int, len(s))
+	for i := range s {
+		values[i] = int(s[i])
+	}
+	return values
+}
+
+//SLIP39Share is a share of SLIP-39 encoded in a mnemonic.
+type SLIP39Share struct {
+	//Identifier is the random 15 bits identifier of the secret, which is
+	//common to all shares.
+	Identifier uint16
+	//Extendable is true if the encryption doesn't depend on Identifier, so
+	//that new sets of shares of the same secret can be created.
+	Extendable bool
+	//IterationExponent is e of 10000*2^e iterations of PBKDF2 in the
+	//encryption of the master secret.
+	IterationExponent int
+	//GroupIndex is the index of the group of the share.
+	GroupIndex int
+	//GroupThreshold is the number of groups needed to recover the secret.
+	GroupThreshold int
+	//GroupCount is the number of groups.
+	GroupCount int
+	//MemberIndex is the index of the share in the group.
+	MemberIndex int
+	//MemberThreshold is the number of shares needed to recover the group
+	//secret.
+	MemberThreshold int
+	//Value is the share value.
+	Value []byte
+}
+
+//ParseSLIP39Share decodes the mnemonic and validates its checksum.
+//An error of type *UnknownWordError, ErrSLIP39Length, ErrSLIP39Checksum,
+//ErrSLIP39Padding or ErrSLIP39Params is returned if it is invalid.
+func ParseSLIP39Share(mnemonic string) (*SLIP39Share, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < slip39MinWords {
+		return nil, ErrSLIP39Length
+	}
+	values := make([]int, len(words))
+	for i, w := range words {
+		v, ok := slip39Index[w]
+		if !ok {
+			return nil, &UnknownWordError{Position: i, Word: w}
+		}
+		values[i] = v
+	}
+	valueWords := len(words) - slip39MetadataWords
+	padding := slip39RadixBits * valueWords % 16
+	if padding > 8 {
+		return nil, ErrSLIP39Length
+	}
+
+	idExp := values[0]<<slip39RadixBits | values[1]
+	s := &SLIP39Share{
+		Identifier:        uint16(idExp >> (slip39IterationExpBits + 1)),
+		Extendable:        (idExp>>slip39IterationExpBits)&1 == 1,
+		IterationExponent: idExp & (1<<slip39IterationExpBits - 1),
+	}
+	if rs1024Polymod(append(slip39Customization(s.Extendable), values...)) != 1 {
+		return nil, ErrSLIP39Checksum
+	}
+	params := values[2]<<slip39RadixBits | values[3]
+	s.GroupIndex = params >> 16
+	s.GroupThreshold = (params>>12)&0xf + 1
+	s.GroupCount = (params>>8)&0xf + 1
+	s.MemberIndex = (params >> 4) & 0xf
+	s.MemberThreshold = params&0xf + 1
+	if s.GroupThreshold > s.GroupCount {
+		return nil, ErrSLIP39Params
+	}
+
+	//The value is big endian with the padding bits at the top.
+	v := new(big.Int)
+	for _, w := range values[4 : len(values)-slip39ChecksumWords] {
+		v.Lsh(v, slip39RadixBits)
+		v.Or(v, big.NewInt(int64(w)))
+	}
+	n := (slip39RadixBits*valueWords - padding) / 8
+	if v.BitLen() > n*8 {
+		return nil, ErrSLIP39Padding
+	}
+	s.Value = v.FillBytes(make([]byte, n))
+	return s, nil
+}
+
+//Mnemonic encodes the share to a mnemonic.
+func (s *SLIP39Share) Mnemonic() string {
+	idExp := int(s.Identifier)<<(slip39IterationExpBits+1) | s.IterationExponent
+	if s.Extendable {
+		idExp |= 1 << slip39IterationExpBits
+	}
+	params := s.GroupIndex<<16 | (s.GroupThreshold-1)<<12 |
+		(s.GroupCount-1)<<8 | s.MemberIndex<<4 | (s.MemberThreshold - 1)
+	values := []int{
+		idExp >> slip39RadixBits, idExp & (1<<slip39RadixBits - 1),
+		params >> slip39RadixBits, params & (1<<slip39RadixBits - 1),
+	}
+
+	//Split the value into 10 bits words with the padding bits at the top.
+	valueWords := (len(s.Value)*8 + slip39RadixBits - 1) / slip39RadixBits
+	v := new(big.Int).SetBytes(s.Value)
+	mask := big.NewInt(1<<slip39RadixBits - 1)
+	for i := valueWords - 1; i >= 0; i-- {
+		w := new(big.Int).Rsh(v, uint(slip39RadixBits*i))
+		values = append(values, int(w.And(w, mask).Int64()))
+	}
+
+	polymod := rs1024Polymod(append(append(slip39Customization(s.Extendable),
+		values...), 0, 0, 0)) ^ 1
+	for k := slip39ChecksumWords - 1; k >= 0; k-- {
+		values = append(values, int(polymod>>uint(slip39RadixBits*k))&(1<<slip39RadixBits-1))
+	}
+	words := make([]string, len(values))
+	for k, v := range values {
+		words[k] = slip39Words[v]
+	}
+	return strings.Join(words, " ")
+}
+
+//rawShare is a point of the polynomial of Shamir's secret sharing.
+type rawShare struct {
+	x    byte
+	data []byte
+}
+
+//interpolate returns the value at x of the polynomial through shares by the
+//Lagrange interpolation over GF(256).
+func interpolate(shares []rawShare, x byte) ([]byte, error) {
+	for i, s := range shares {
+		for _, o := range shares[i+1:] {
+			if s.x == o.x {
+				return nil, ErrSLIP39Mismatch
+			}
+		}
+		if len(s.data) != len(shares[0].data) {
+			return nil, ErrSLIP39Mismatch
+		}
+	}
+	for _, s := range shares {
+		if s.x == x {
+			return s.data, nil
+		}
+	}
+	logProd := 0
+	for _, s := range shares {
+		logProd += gf256Log[s.x^x]
+	}
+	result := make([]byte, len(shares[0].data))
+	for _, s := range shares {
+		logBasis := logProd - gf256Log[s.x^x]
+		for _, o := range shares {
+			if o.x != s.x {
+				logBasis -= gf256Log[s.x^o.x]
+			}
+		}
+		logBasis = (logBasis%255 + 255) % 255
+		for i, v := range s.data {
+			if v != 0 {
+				result[i] ^= gf256Exp[(gf256Log[v]+logBasis)%255]
+			}
+		}
+	}
+	return result, nil
+}
+
+//slip39Digest returns the digest of the shared secret.
+func slip39Digest(random, secret []byte) []byte {
+	mac := hmac.New(sha256.New, random)
+	mac.Write(secret)
+	return mac.Sum(nil)[:slip39DigestLen]
+}
+
+//splitSecret splits secret into count shares with threshold.
+func splitSecret(threshold, count int, secret []byte) ([]rawShare, error) {
+	shares := make([]rawShare, 0, count)
+	if threshold == 1 {
+		for i := 0; i < count; i++ {
+			shares = append(shares, rawShare{byte(i), secret})
+		}
+		return shares, nil
+	}
+	for i := 0; i < threshold-2; i++ {
+		r := make([]byte, len(secret))
+		if _, err := rand.Read(r); err != nil {
+			return nil, err
+		}
+		shares = append(shares, rawShare{byte(i), r})
+	}
+	random := make([]byte, len(secret)-slip39DigestLen)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	base := append(shares[:len(shares):len(shares)],
+		rawShare{slip39DigestIndex, append(slip39Digest(random, secret), random...)},
+		rawShare{slip39SecretIndex, secret})
+	for i := threshold - 2; i < count; i++ {
+		v, err := interpolate(base, byte(i))
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, rawShare{byte(i), v})
+	}
+	return shares, nil
+}
+
+//recoverSecret recovers the secret from threshold shares and checks its
+//digest.
+func recoverSecret(threshold int, shares []rawShare) ([]byte, error) {
+	if threshold == 1 {
+		return shares[0].data, nil
+	}
+	secret, err := interpolate(shares, slip39SecretIndex)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := interpolate(shares, slip39DigestIndex)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(digest[:slip39DigestLen],
+		slip39Digest(digest[slip39DigestLen:], secret)) {
+		return nil, ErrSLIP39Digest
+	}
+	return secret, nil
+}
+
+//slip39Feistel runs the 4 rounds Feistel cipher of SLIP-39 forward to
+//encrypt or backward to decrypt.
+func slip39Feistel(secret []byte, passphrase string, e int, id uint16,
+	extendable, encrypt bool) []byte {
+
+	half := len(secret) / 2
+	l := append([]byte{}, secret[:half]...)
+	r := append([]byte{}, secret[half:]...)
+	var salt []byte
+	if !extendable {
+		salt = []byte{'s', 'h', 'a', 'm', 'i', 'r', byte(id >> 8), byte(id)}
+	}
+	iterations := (slip39BaseIterations << uint(e)) / slip39Rounds
+	for k := 0; k < slip39Rounds; k++ {
+		i := k
+		if !encrypt {
+			i = slip39Rounds - 1 - k
+		}
+		f := pbkdf2.Key(append([]byte{byte(i)}, passphrase...),
+			append(append([]byte{}, salt...), r...), iterations, len(r),
+			sha256.New)
+		for j := range f {
+			f[j] ^= l[j]
+		}
+		l, r = r, f
+	}
+	return append(r, l...)
+}
+
+func validSLIP39Passphrase(passphrase string) bool {
+	for i := 0; i < len(passphrase); i++ {
+		if passphrase[i] < 32 || passphrase[i] > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+//SLIP39Group is the parameters of a group of shares.
+type SLIP39Group struct {
+	//MemberThreshold is the number of shares needed to recover the group
+	//secret.
+	MemberThreshold int
+	//MemberCount is the number of shares in the group.
+	MemberCount int
+}
+
+//NewSLIP39Mnemonics splits masterSecret, such as the entropy of NewEntropy,
+//into groups of mnemonic shares, where groupThreshold groups, with the
+//member threshold of shares in each group, are needed to recover it.
+//The master secret is encrypted with the printable ASCII passphrase and
+//10000*2^iterationExponent iterations of PBKDF2. If extendable is true,
+//another set of shares of the same master secret can be created later
+//with the same identifier.
+func NewSLIP39Mnemonics(masterSecret []byte, passphrase string, groupThreshold int,
+	groups []SLIP39Group, iterationExponent int, extendable bool) ([][]string, error) {
+
+	if len(masterSecret) < slip39MinSecretLen || len(masterSecret)%2 != 0 {
+		return nil, ErrSLIP39SecretLen
+	}
+	if !validSLIP39Passphrase(passphrase) {
+		return nil, ErrSLIP39Passphrase
+	}
+	if len(groups) < 1 || len(groups) > SLIP39MaxShares ||
+		groupThreshold < 1 || groupThreshold > len(groups) ||
+		iterationExponent < 0 || iterationExponent >= 1<<slip39IterationExpBits {
+		return nil, ErrSLIP39Params
+	}
+	for _, g := range groups {
+		//Multiple shares with threshold 1 would be identical copies.
+		if g.MemberThreshold < 1 || g.MemberThreshold > g.MemberCount ||
+			g.MemberCount > SLIP39MaxShares ||
+			(g.MemberThreshold == 1 && g.MemberCount > 1) {
+			return nil, ErrSLIP39Params
+		}
+	}
+
+	var idb [2]byte
+	if _, err := rand.Read(idb[:]); err != nil {
+		return nil, err
+	}
+	id := (uint16(idb[0])<<8 | uint16(idb[1])) & (1<<slip39IDBits - 1)
+	ems := slip39Feistel(masterSecret, passphrase, iterationExponent, id,
+		extendable, true)
+
+	groupShares, err := splitSecret(groupThreshold, len(groups), ems)
+	if err != nil {
+		return nil, err
+	}
+	mnemonics := make([][]string, len(groups))
+	for i, g := range groups {
+		members, err := splitSecret(g.MemberThreshold, g.MemberCount,
+			groupShares[i].data)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			s := &SLIP39Share{
+				Identifier:        id,
+				Extendable:        extendable,
+				IterationExponent: iterationExponent,
+				GroupIndex:        i,
+				GroupThreshold:    groupThreshold,
+				GroupCount:        len(groups),
+				MemberIndex:       int(m.x),
+				MemberThreshold:   g.MemberThreshold,
+				Value:             m.data,
+			}
+			mnemonics[i] = append(mnemonics[i], s.Mnemonic())
+		}
+	}
+	return mnemonics, nil
+}
+
+//CombineSLIP39Mnemonics recovers the master secret from the mnemonic shares
+//and the passphrase. Exactly the threshold number of groups, each with
+//exactly its member threshold of shares, must be given; otherwise
+//*SLIP39ThresholdError is returned. A wrong passphrase can't be detected
+//and results in a different master secret.
+func CombineSLIP39Mnemonics(mnemonics []string, passphrase string) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, ErrSLIP39NoShares
+	}
+	if !validSLIP39Passphrase(passphrase) {
+		return nil, ErrSLIP39Passphrase
+	}
+	var first *SLIP39Share
+	groups := make(map[int][]*SLIP39Share)
+	for i, m := range mnemonics {
+		s, err := ParseSLIP39Share(m)
+		if err != nil {
+			return nil, fmt.Errorf("mnemonic %d: %w", i, err)
+		}
+		if first == nil {
+			first = s
+		}
+		if s.Identifier != first.Identifier || s.Extendable != first.Extendable ||
+			s.IterationExponent != first.IterationExponent ||
+			s.GroupThreshold != first.GroupThreshold ||
+			s.GroupCount != first.GroupCount ||
+			len(s.Value) != len(first.Value) {
+			return nil, fmt.Errorf("%w: mnemonic %d", ErrSLIP39Mismatch, i)
+		}
+		dup := false
+		for _, o := range groups[s.GroupIndex] {
+			if o.MemberThreshold != s.MemberThreshold {
+				return nil, fmt.Errorf("%w: mnemonic %d", ErrSLIP39Mismatch, i)
+			}
+			if o.MemberIndex == s.MemberIndex {
+				if !bytes.Equal(o.Value, s.Value) {
+					return nil, fmt.Errorf("%w: mnemonic %d", ErrSLIP39Mismatch, i)
+				}
+				dup = true
+			}
+		}
+		if !dup {
+			groups[s.GroupIndex] = append(groups[s.GroupIndex], s)
+		}
+	}
+	if len(first.Value) < slip39MinSecretLen || len(first.Value)%2 != 0 {
+		return nil, ErrSLIP39Length
+	}
+	if len(groups) != first.GroupThreshold {
+		return nil, &SLIP39ThresholdError{
+			Group:     -1,
+			Threshold: first.GroupThreshold,
+			Given:     len(groups),
+		}
+	}
+
+	indexes := make([]int, 0, len(groups))
+	for gi := range groups {
+		indexes = append(indexes, gi)
+	}
+	sort.Ints(indexes)
+	groupShares := make([]rawShare, 0, len(groups))
+	for _, gi := range indexes {
+		members := groups[gi]
+		threshold := members[0].MemberThreshold
+		if len(members) != threshold {
+			return nil, &SLIP39ThresholdError{
+				Group:     gi,
+				Threshold: threshold,
+				Given:     len(members),
+			}
+		}
+		raw := make([]rawShare, len(members))
+		for i, m := range members {
+			raw[i] = rawShare{byte(m.MemberIndex), m.Value}
+		}
+		secret, err := recoverSecret(threshold, raw)
+		if err != nil {
+			return nil, err
+		}
+		groupShares = append(groupShares, rawShare{byte(gi), secret})
+	}
+	ems, err := recoverSecret(first.GroupThreshold, groupShares)
+	if err != nil {
+		return nil, err
+	}
+	return slip39Feistel(ems, passphrase, first.IterationExponent,
+		first.Identifier, first.Extendable, false), nil
+}
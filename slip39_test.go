@@ -0,0 +1,230 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+//test vectors from vectors.json of python-shamir-mnemonic, whose passphrase is
+//"TREZOR". Cases 18, 37 and 38 are not included.
+func TestSLIP39Vectors(t *testing.T) {
+	file, err := os.ReadFile("testdata/slip39/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors [][]json.RawMessage
+	if err := json.Unmarshal(file, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		var desc, secret, xprv string
+		var mnemonics []string
+		for i, dst := range []interface{}{&desc, &mnemonics, &secret, &xprv} {
+			if err := json.Unmarshal(v[i], dst); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s, err := CombineSLIP39Mnemonics(mnemonics, "TREZOR")
+		if secret == "" {
+			if err == nil {
+				t.Errorf("%s: no error", desc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(desc, err)
+		}
+		if hex.EncodeToString(s) != secret {
+			t.Errorf("%s: got %x", desc, s)
+		}
+		master, err := NewMaster(s, BitcoinMain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if master.String() != xprv {
+			t.Errorf("%s: got %s", desc, master)
+		}
+		for _, m := range mnemonics {
+			share, err := ParseSLIP39Share(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if share.Mnemonic() != m {
+				t.Errorf("%s: got %s", desc, share.Mnemonic())
+			}
+		}
+	}
+}
+
+func TestSLIP39Invalid(t *testing.T) {
+	tests := []struct {
+		mnemonics []string
+		err       error
+	}{
+		{
+			[]string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision kidney"},
+			ErrSLIP39Checksum,
+		},
+		{
+			[]string{"duckling enlarge academic academic email result length solution fridge kidney coal piece deal husband erode duke ajar music cargo fitness"},
+			ErrSLIP39Padding,
+		},
+		{
+			[]string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision"},
+			ErrSLIP39Length,
+		},
+		{
+			[]string{
+				"adequate smoking academic acid debut wine petition glen cluster slow rhyme slow simple epidemic rumor junk tracks treat olympic tolerate",
+				"adequate stay academic agency agency formal party ting frequent learn upstairs remember smear leaf damage anatomy ladle market hush corner",
+			},
+			ErrSLIP39Mismatch,
+		},
+		{
+			[]string{
+				"peasant leaves academic acid desert exact olympic math alive axle trial tackle drug deny decent smear dominant desert bucket remind",
+				"peasant leader academic agency cultural blessing percent network envelope medal junk primary human pumps jacket fragment payroll ticket evoke voice",
+			},
+			ErrSLIP39Mismatch,
+		},
+		{
+			nil,
+			ErrSLIP39NoShares,
+		},
+	}
+	for i, test := range tests {
+		if _, err := CombineSLIP39Mnemonics(test.mnemonics, "TREZOR"); !errors.Is(err, test.err) {
+			t.Errorf("%d: got %v, expected %v", i, err, test.err)
+		}
+	}
+
+	_, err := ParseSLIP39Share("duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboarx")
+	var uerr *UnknownWordError
+	if !errors.As(err, &uerr) || uerr.Position != 19 {
+		t.Errorf("got %v", err)
+	}
+
+	//one of two shares.
+	_, err = CombineSLIP39Mnemonics([]string{
+		"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed",
+	}, "TREZOR")
+	var terr *SLIP39ThresholdError
+	if !errors.As(err, &terr) || terr.Group != 0 || terr.Threshold != 2 || terr.Given != 1 {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestSLIP39Split(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x5a, 0xa5}, 16)
+	groups := []SLIP39Group{{1, 1}, {2, 3}, {3, 5}}
+	for _, extendable := range []bool{false, true} {
+		mnemonics, err := NewSLIP39Mnemonics(secret, "pass phrase", 2, groups, 0, extendable)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, g := range groups {
+			if len(mnemonics[i]) != g.MemberCount {
+				t.Fatal("invalid number of shares", i, len(mnemonics[i]))
+			}
+			for _, m := range mnemonics[i] {
+				if n := len(strings.Fields(m)); n != 33 {
+					t.Fatal("invalid number of words", n)
+				}
+			}
+		}
+
+		tests := []struct {
+			mnemonics []string
+			err       *SLIP39ThresholdError
+		}{
+			{append([]string{mnemonics[0][0]}, mnemonics[1][1:]...), nil},
+			{append(mnemonics[1][:2:2], mnemonics[2][2:]...), nil},
+			{append([]string{mnemonics[0][0]}, mnemonics[2][1:4]...), nil},
+			{mnemonics[2][:3], &SLIP39ThresholdError{-1, 2, 1}},
+			{append([]string{mnemonics[0][0]}, mnemonics[2][1:3]...), &SLIP39ThresholdError{2, 3, 2}},
+			{append(mnemonics[1][:2:2], mnemonics[2][1], mnemonics[2][1], mnemonics[2][2]), &SLIP39ThresholdError{2, 3, 2}},
+			{append(mnemonics[0][:1:1], mnemonics[1][0], mnemonics[2][0], mnemonics[2][1], mnemonics[2][2]), &SLIP39ThresholdError{-1, 2, 3}},
+		}
+		for i, test := range tests {
+			s, err := CombineSLIP39Mnemonics(test.mnemonics, "pass phrase")
+			if test.err == nil {
+				if err != nil {
+					t.Fatal(i, err)
+				}
+				if !bytes.Equal(s, secret) {
+					t.Errorf("%d: got %x", i, s)
+				}
+				continue
+			}
+			var terr *SLIP39ThresholdError
+			if !errors.As(err, &terr) || *terr != *test.err {
+				t.Errorf("%d: got %v, expected %v", i, err, test.err)
+			}
+		}
+
+		s, err := CombineSLIP39Mnemonics(append(mnemonics[0][:1:1], mnemonics[1][:2]...), "wrong")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(s, secret) {
+			t.Error("recovered with a wrong passphrase")
+		}
+	}
+
+	params := []struct {
+		secret    []byte
+		threshold int
+		groups    []SLIP39Group
+		exp       int
+		err       error
+	}{
+		{secret[:15], 1, []SLIP39Group{{1, 1}}, 0, ErrSLIP39SecretLen},
+		{secret[:17], 1, []SLIP39Group{{1, 1}}, 0, ErrSLIP39SecretLen},
+		{secret, 2, []SLIP39Group{{1, 1}}, 0, ErrSLIP39Params},
+		{secret, 1, []SLIP39Group{{1, 2}}, 0, ErrSLIP39Params},
+		{secret, 1, []SLIP39Group{{3, 2}}, 0, ErrSLIP39Params},
+		{secret, 1, []SLIP39Group{{2, 17}}, 0, ErrSLIP39Params},
+		{secret, 1, []SLIP39Group{{1, 1}}, 16, ErrSLIP39Params},
+	}
+	for i, p := range params {
+		if _, err := NewSLIP39Mnemonics(p.secret, "", p.threshold, p.groups, p.exp, false); err != p.err {
+			t.Errorf("%d: got %v, expected %v", i, err, p.err)
+		}
+	}
+	if _, err := NewSLIP39Mnemonics(secret, "é", 1, groups[:1], 0, false); err != ErrSLIP39Passphrase {
+		t.Error("non ASCII passphrase was accepted")
+	}
+}
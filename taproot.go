@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+// References:
+//   [BIP340]: Schnorr Signatures for secp256k1
+//   https://github.com/bitcoin/bips/blob/master/bip-0340.mediawiki
+//   [BIP341]: Taproot: SegWit version 1 spending rules
+//   https://github.com/bitcoin/bips/blob/master/bip-0341.mediawiki
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/bitgoin/address/bech32"
+	"github.com/bitgoin/address/btcec"
+)
+
+var (
+	//ErrInvalidMerkleRoot is returned when the taproot merkle root is not
+	//empty and not 32 bytes.
+	ErrInvalidMerkleRoot = errors.New("merkle root must be empty or 32 bytes")
+
+	//ErrInvalidTweak is returned when the tweak is out of the curve order or
+	//the tweaked key is the point at infinity.
+	ErrInvalidTweak = errors.New("invalid taproot tweak")
+)
+
+//XOnly returns the 32 bytes x-only public key defined in BIP340.
+func (pub *PublicKey) XOnly() []byte {
+	return pub.SerializeXOnly()
+}
+
+//taprootTweak returns the BIP341 tweak of the x-only public key with
+//merkleRoot, which may be empty for the key path only outputs.
+func taprootTweak(xonly, merkleRoot []byte) (*big.Int, error) {
+	if len(merkleRoot) != 0 && len(merkleRoot) != 32 {
+		return nil, ErrInvalidMerkleRoot
+	}
+	t := new(big.Int).SetBytes(btcec.TaggedHash("TapTweak", xonly, merkleRoot))
+	if t.Cmp(secp256k1.N) >= 0 {
+		return nil, ErrInvalidTweak
+	}
+	return t, nil
+}
+
+//TaprootOutputKey returns the x-only output key of taproot, which is the
+//internal key (pub) tweaked by merkleRoot of the script tree.
+//merkleRoot can be nil if there is no script tree.
+func (pub *PublicKey) TaprootOutputKey(merkleRoot []byte) ([]byte, error) {
+	xonly := pub.XOnly()
+	t, err := taprootTweak(xonly, merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	//Q = P + t*G , where P is the point with even y.
+	px, py := pub.X, pub.Y
+	if py.Bit(0) == 1 {
+		py = new(big.Int).Sub(secp256k1.P, py)
+	}
+	tx, ty := secp256k1.ScalarBaseMult(t.Bytes())
+	qx, qy := secp256k1.Add(px, py, tx, ty)
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return nil, ErrInvalidTweak
+	}
+	q := btcec.PublicKey{Curve: secp256k1, X: qx, Y: qy}
+	return q.SerializeCompressed()[1:], nil
+}
+
+//TaprootAddress returns segwit v1 (P2TR) address from PublicKey used as the
+//internal key. merkleRoot can be nil if there is no script tree.
+func (pub *PublicKey) TaprootAddress(merkleRoot []byte) (string, error) {
+	q, err := pub.TaprootOutputKey(merkleRoot)
+	if err != nil {
+		return "", err
+	}
+	return bech32.EncodeSegwit(pub.param.Bech32HRP, 1, q)
+}
+
+//TaprootTweak returns the private key of the taproot output key for
+//merkleRoot, which can be used for signing key path spends.
+//merkleRoot can be nil if there is no script tree.
+func (priv *PrivateKey) TaprootTweak(merkleRoot []byte) (*PrivateKey, error) {
+	t, err := taprootTweak(priv.PublicKey.XOnly(), merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	d := new(big.Int).Set(priv.D)
+	if priv.PublicKey.Y.Bit(0) == 1 {
+		d.Sub(secp256k1.N, d)
+	}
+	d.Add(d, t)
+	d.Mod(d, secp256k1.N)
+	if d.Sign() == 0 {
+		return nil, ErrInvalidTweak
+	}
+	pb := make([]byte, btcec.PrivKeyBytesLen)
+	b := d.Bytes()
+	copy(pb[len(pb)-len(b):], b)
+	return NewPrivateKey(pb, priv.PublicKey.param), nil
+}
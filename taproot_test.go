@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+//test vectors from BIP341 wallet-test-vectors.json (scriptPubKey).
+func TestTaprootAddress(t *testing.T) {
+	tests := []struct {
+		internal   string
+		merkleRoot string
+		tweaked    string
+		address    string
+	}{
+		{
+			internal: "d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d",
+			tweaked:  "53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343",
+			address:  "bc1p2wsldez5mud2yam29q22wgfh9439spgduvct83k3pm50fcxa5dps59h4z5",
+		},
+		{
+			internal:   "187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27",
+			merkleRoot: "5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21",
+			tweaked:    "147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3",
+			address:    "bc1pz37fc4cn9ah8anwm4xqqhvxygjf9rjf2resrw8h8w4tmvcs0863sa2e586",
+		},
+	}
+	for _, test := range tests {
+		internal, err := hex.DecodeString("02" + test.internal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, err := hex.DecodeString(test.merkleRoot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := NewPublicKey(internal, BitcoinMain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := pub.TaprootOutputKey(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(q) != test.tweaked {
+			t.Error("invalid tweaked key", hex.EncodeToString(q))
+		}
+		adr, err := pub.TaprootAddress(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if adr != test.address {
+			t.Error("invalid taproot address", adr)
+		}
+	}
+}
+
+//test vectors from BIP86.
+func TestTaprootTweak(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	master, err := NewMaster(NewSeed(mnemonic, ""), BitcoinMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path     []uint32
+		internal string
+		output   string
+		address  string
+	}{
+		{
+			path:     []uint32{HardenedKeyStart + 86, HardenedKeyStart, HardenedKeyStart, 0, 0},
+			internal: "cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115",
+			output:   "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+			address:  "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+		},
+		{
+			path:     []uint32{HardenedKeyStart + 86, HardenedKeyStart, HardenedKeyStart, 0, 1},
+			internal: "83dfe85a3151d2517290da461fe2815591ef69f2b18a2ce63f01697a8b313145",
+			output:   "a82f29944d65b86ae6b5e5cc75e294ead6c59391a1edc5e016e3498c67fc7bbb",
+			address:  "bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh",
+		},
+		{
+			path:     []uint32{HardenedKeyStart + 86, HardenedKeyStart, HardenedKeyStart, 1, 0},
+			internal: "399f1b2f4393f29a18c937859c5dd8a77350103157eb880f02e8c08214277cef",
+			output:   "882d74e5d0572d5a816cef0041a96b6c1de832f6f9676d9605c44d5e9a97d3dc",
+			address:  "bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7",
+		},
+	}
+	for _, test := range tests {
+		k := master
+		for _, i := range test.path {
+			if k, err = k.Child(i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		priv, err := k.PrivKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(priv.PublicKey.XOnly()) != test.internal {
+			t.Error("invalid internal key", hex.EncodeToString(priv.PublicKey.XOnly()))
+		}
+		adr, err := priv.PublicKey.TaprootAddress(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if adr != test.address {
+			t.Error("invalid taproot address", adr)
+		}
+		tweaked, err := priv.TaprootTweak(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := hex.DecodeString(test.output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(tweaked.PublicKey.XOnly(), output) {
+			t.Error("invalid tweaked private key", hex.EncodeToString(tweaked.PublicKey.XOnly()))
+		}
+	}
+}
@@ -0,0 +1,400 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+//ErrInvalidTx is returned when a transaction is malformed.
+var ErrInvalidTx = errors.New("invalid transaction")
+
+//maxTxItems is the maximum number of inputs, outputs and witness items
+//of a transaction to be read, to avoid huge allocations.
+const maxTxItems = 1 << 16
+
+//OutPoint is a reference to an output of a previous transaction.
+type OutPoint struct {
+	//Hash is the txid of the previous transaction in internal byte order.
+	Hash  []byte
+	Index uint32
+}
+
+//TxIn is an input of a transaction.
+type TxIn struct {
+	PreviousOutPoint OutPoint
+	SignatureScript  []byte
+	Witness          [][]byte
+	Sequence         uint32
+}
+
+//TxOut is an output of a transaction.
+type TxOut struct {
+	Value    int64
+	PkScript []byte
+}
+
+//Tx is a bitcoin transaction.
+type Tx struct {
+	Version  int32
+	TxIn     []*TxIn
+	TxOut    []*TxOut
+	LockTime uint32
+}
+
+//hasWitness returns true if any input of the tx has witness.
+func (tx *Tx) hasWitness() bool {
+	for _, in := range tx.TxIn {
+		if len(in.Witness) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+//Serialize returns the serialized tx including witness if any.
+func (tx *Tx) Serialize() []byte {
+	var buf bytes.Buffer
+	tx.serialize(&buf, tx.hasWitness())
+	return buf.Bytes()
+}
+
+//SerializeNoWitness returns the serialized tx without witness.
+func (tx *Tx) SerializeNoWitness() []byte {
+	var buf bytes.Buffer
+	tx.serialize(&buf, false)
+	return buf.Bytes()
+}
+
+//TxHash returns the txid of the tx in internal byte order.
+func (tx *Tx) TxHash() []byte {
+	return doubleSHA256(tx.SerializeNoWitness())
+}
+
+func (tx *Tx) serialize(w *bytes.Buffer, witness bool) {
+	writeUint32(w, uint32(tx.Version))
+	if witness {
+		w.Write([]byte{0x00, 0x01})
+	}
+	writeVarInt(w, uint64(len(tx.TxIn)))
+	for _, in := range tx.TxIn {
+		w.Write(in.PreviousOutPoint.Hash)
+		writeUint32(w, in.PreviousOutPoint.Index)
+		writeVarBytes(w, in.SignatureScript)
+		writeUint32(w, in.Sequence)
+	}
+	writeVarInt(w, uint64(len(tx.TxOut)))
+	for _, out := range tx.TxOut {
+		out.serialize(w)
+	}
+	if witness {
+		for _, in := range tx.TxIn {
+			writeWitness(w, in.Witness)
+		}
+	}
+	writeUint32(w, tx.LockTime)
+}
+
+//Serialize returns the serialized output.
+func (out *TxOut) Serialize() []byte {
+	var buf bytes.Buffer
+	out.serialize(&buf)
+	return buf.Bytes()
+}
+
+func (out *TxOut) serialize(w *bytes.Buffer) {
+	writeUint64(w, uint64(out.Value))
+	writeVarBytes(w, out.PkScript)
+}
+
+//Copy returns a deep copy of the tx.
+func (tx *Tx) Copy() *Tx {
+	c := &Tx{
+		Version:  tx.Version,
+		TxIn:     make([]*TxIn, len(tx.TxIn)),
+		TxOut:    make([]*TxOut, len(tx.TxOut)),
+		LockTime: tx.LockTime,
+	}
+	for i, in := range tx.TxIn {
+		cin := *in
+		cin.PreviousOutPoint.Hash = append([]byte{}, in.PreviousOutPoint.Hash...)
+		cin.SignatureScript = append([]byte{}, in.SignatureScript...)
+		cin.Witness = nil
+		for _, item := range in.Witness {
+			cin.Witness = append(cin.Witness, append([]byte{}, item...))
+		}
+		c.TxIn[i] = &cin
+	}
+	for i, out := range tx.TxOut {
+		c.TxOut[i] = &TxOut{
+			Value:    out.Value,
+			PkScript: append([]byte{}, out.PkScript...),
+		}
+	}
+	return c
+}
+
+//ParseTx parses a serialized transaction with or without witness.
+func ParseTx(b []byte) (*Tx, error) {
+	return parseTx(b, true)
+}
+
+//ParseTxNoWitness parses a transaction serialized without witness, which
+//is needed to parse txs without inputs, e.g. unsigned txs in PSBTs.
+func ParseTxNoWitness(b []byte) (*Tx, error) {
+	return parseTx(b, false)
+}
+
+func parseTx(b []byte, allowWitness bool) (*Tx, error) {
+	r := bytes.NewReader(b)
+	tx, err := readTx(r, allowWitness)
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, ErrInvalidTx
+	}
+	return tx, nil
+}
+
+func readTx(r *bytes.Reader, allowWitness bool) (*Tx, error) {
+	tx := &Tx{}
+	v, err := readUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	tx.Version = int32(v)
+	nin, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	witness := false
+	if nin == 0 && allowWitness {
+		flag, err := r.ReadByte()
+		if err != nil {
+			return nil, ErrInvalidTx
+		}
+		if flag != 0x01 {
+			return nil, ErrInvalidTx
+		}
+		witness = true
+		if nin, err = readVarInt(r); err != nil {
+			return nil, err
+		}
+	}
+	if nin > maxTxItems {
+		return nil, ErrInvalidTx
+	}
+	tx.TxIn = make([]*TxIn, nin)
+	for i := range tx.TxIn {
+		in := &TxIn{}
+		in.PreviousOutPoint.Hash = make([]byte, 32)
+		if _, err := io.ReadFull(r, in.PreviousOutPoint.Hash); err != nil {
+			return nil, ErrInvalidTx
+		}
+		if in.PreviousOutPoint.Index, err = readUint32(r); err != nil {
+			return nil, err
+		}
+		if in.SignatureScript, err = readVarBytes(r); err != nil {
+			return nil, err
+		}
+		if in.Sequence, err = readUint32(r); err != nil {
+			return nil, err
+		}
+		tx.TxIn[i] = in
+	}
+	nout, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if nout > maxTxItems {
+		return nil, ErrInvalidTx
+	}
+	tx.TxOut = make([]*TxOut, nout)
+	for i := range tx.TxOut {
+		if tx.TxOut[i], err = readTxOut(r); err != nil {
+			return nil, err
+		}
+	}
+	if witness {
+		for _, in := range tx.TxIn {
+			if in.Witness, err = readWitness(r); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if tx.LockTime, err = readUint32(r); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+//ParseTxOut parses a serialized output.
+func ParseTxOut(b []byte) (*TxOut, error) {
+	r := bytes.NewReader(b)
+	out, err := readTxOut(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, ErrInvalidTx
+	}
+	return out, nil
+}
+
+func readTxOut(r *bytes.Reader) (*TxOut, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return nil, ErrInvalidTx
+	}
+	script, err := readVarBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	return &TxOut{
+		Value:    int64(binary.LittleEndian.Uint64(b[:])),
+		PkScript: script,
+	}, nil
+}
+
+func readWitness(r *bytes.Reader) ([][]byte, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxTxItems {
+		return nil, ErrInvalidTx
+	}
+	var wit [][]byte
+	for i := uint64(0); i < n; i++ {
+		item, err := readVarBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		wit = append(wit, item)
+	}
+	return wit, nil
+}
+
+func writeWitness(w *bytes.Buffer, wit [][]byte) {
+	writeVarInt(w, uint64(len(wit)))
+	for _, item := range wit {
+		writeVarBytes(w, item)
+	}
+}
+
+func writeUint32(w *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	w.Write(b[:])
+}
+
+func writeUint64(w *bytes.Buffer, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	w.Write(b[:])
+}
+
+func readUint32(r *bytes.Reader) (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, ErrInvalidTx
+	}
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+func writeVarInt(w *bytes.Buffer, v uint64) {
+	var b [9]byte
+	switch {
+	case v < 0xfd:
+		w.WriteByte(byte(v))
+	case v <= 0xffff:
+		b[0] = 0xfd
+		binary.LittleEndian.PutUint16(b[1:], uint16(v))
+		w.Write(b[:3])
+	case v <= 0xffffffff:
+		b[0] = 0xfe
+		binary.LittleEndian.PutUint32(b[1:], uint32(v))
+		w.Write(b[:5])
+	default:
+		b[0] = 0xff
+		binary.LittleEndian.PutUint64(b[1:], v)
+		w.Write(b[:])
+	}
+}
+
+//readVarInt reads a compact size uint, which must be encoded canonically.
+func readVarInt(r *bytes.Reader) (uint64, error) {
+	d, err := r.ReadByte()
+	if err != nil {
+		return 0, ErrInvalidTx
+	}
+	var b [8]byte
+	var v, min uint64
+	switch d {
+	case 0xfd:
+		_, err = io.ReadFull(r, b[:2])
+		v, min = uint64(binary.LittleEndian.Uint16(b[:])), 0xfd
+	case 0xfe:
+		_, err = io.ReadFull(r, b[:4])
+		v, min = uint64(binary.LittleEndian.Uint32(b[:])), 0x10000
+	case 0xff:
+		_, err = io.ReadFull(r, b[:])
+		v, min = binary.LittleEndian.Uint64(b[:]), 0x100000000
+	default:
+		return uint64(d), nil
+	}
+	if err != nil || v < min {
+		return 0, ErrInvalidTx
+	}
+	return v, nil
+}
+
+func writeVarBytes(w *bytes.Buffer, b []byte) {
+	writeVarInt(w, uint64(len(b)))
+	w.Write(b)
+}
+
+func readVarBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, ErrInvalidTx
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, ErrInvalidTx
+	}
+	return b, nil
+}
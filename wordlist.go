@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2016, Shinya Yagyu
+ * All rights reserved.
+ * Redistribution and use in source and binary forms, with or without
+ * modification, are permitted provided that the following conditions are met:
+ *
+ * 1. Redistributions of source code must retain the above copyright notice,
+ *    this list of conditions and the following disclaimer.
+ * 2. Redistributions in binary form must reproduce the above copyright notice,
+ *    this list of conditions and the following disclaimer in the documentation
+ *    and/or other materials provided with the distribution.
+ * 3. Neither the name of the copyright holder nor the names of its
+ *    contributors may be used to endorse or promote products derived from this
+ *    software without specific prior written permission.
+ *
+ * THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+ * AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+ * IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE
+ * ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE
+ * LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR
+ * CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF
+ * SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS
+ * INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN
+ * CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE)
+ * ARISING IN ANY WAY OUT OF THE USE OF THIS SOFTWARE, EVEN IF ADVISED OF THE
+ * POSSIBILITY OF SUCH DAMAGE.
+ */
+
+package address
+
+// References:
+//   [BIP39]: Mnemonic code for generating deterministic keys
+//   https://github.com/bitcoin/bips/blob/master/bip-0039.mediawiki
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+//ErrUnknownWordlist is returned when no wordlist contains all words of the
+//mnemonic.
+var ErrUnknownWordlist = errors.New("cannot detect the wordlist of the mnemonic")
+
+//Wordlist is a list of 2048 words used to encode entropy to a mnemonic.
+type Wordlist struct {
+	//Name is the language of the wordlist.
+	Name string
+	//Separator is the string joining words in the mnemonic.
+	Separator string
+
+	words []string
+	index map[string]int
+}
+
+//Official wordlists of BIP39.
+var (
+	English            = NewWordlist("english", " ", englishWords)
+	Japanese           = NewWordlist("japanese", "　", japaneseWords)
+	Korean             = NewWordlist("korean", " ", koreanWords)
+	Spanish            = NewWordlist("spanish", " ", spanishWords)
+	ChineseSimplified  = NewWordlist("chinese_simplified", " ", chineseSimplifiedWords)
+	ChineseTraditional = NewWordlist("chinese_traditional", " ", chineseTraditionalWords)
+	French             = NewWordlist("french", " ", frenchWords)
+	Italian            = NewWordlist("italian", " ", italianWords)
+	Czech              = NewWordlist("czech", " ", czechWords)
+	Portuguese         = NewWordlist("portuguese", " ", portugueseWords)
+)
+
+//Wordlists is the list of wordlists tried in order when detecting the
+//language of a mnemonic.
+var Wordlists = []*Wordlist{
+	English, Japanese, Korean, Spanish, ChineseSimplified, ChineseTraditional,
+	French, Italian, Czech, Portuguese,
+}
+
+//NewWordlist returns Wordlist from 2048 words.
+//Words are looked up after NFKD normalisation.
+func NewWordlist(name, separator string, words []string) *Wordlist {
+	if len(words) != 2048 {
+		panic("wordlist " + name + " must have 2048 words")
+	}
+	w := &Wordlist{
+		Name:      name,
+		Separator: separator,
+		words:     words,
+		index:     make(map[string]int, len(words)),
+	}
+	for i, word := range words {
+		w.index[norm.NFKD.String(word)] = i
+	}
+	return w
+}
+
+//Word returns the i-th word.
+func (w *Wordlist) Word(i int) string {
+	return w.words[i]
+}
+
+//Index returns the index of word and true if the wordlist contains it.
+func (w *Wordlist) Index(word string) (int, bool) {
+	i, ok := w.index[norm.NFKD.String(word)]
+	return i, ok
+}
+
+//Join joins words with the separator of the wordlist.
+func (w *Wordlist) Join(words []string) string {
+	return strings.Join(words, w.Separator)
+}
+
+//splitMnemonic splits mnemonic to NFKD normalised words.
+//The ideographic space used by Japanese mnemonics is also a separator.
+func splitMnemonic(mnemonic string) []string {
+	return strings.Fields(norm.NFKD.String(mnemonic))
+}
+
+//DetectWordlist returns the first wordlist in Wordlists which contains all
+//words of mnemonic.
+func DetectWordlist(mnemonic string) (*Wordlist, error) {
+	words := splitMnemonic(mnemonic)
+	if len(words) == 0 {
+		return nil, ErrUnknownWordlist
+	}
+next:
+	for _, wl := range Wordlists {
+		for _, word := range words {
+			if _, ok := wl.Index(word); !ok {
+				continue next
+			}
+		}
+		return wl, nil
+	}
+	return nil, ErrUnknownWordlist
+}
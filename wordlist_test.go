@@ -0,0 +1,134 @@
+// The MIT License (MIT)
+
+// Copyright (c) 2014 Tyler Smith
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package address
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestWordlists(t *testing.T) {
+	entropy, err := hex.DecodeString("7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, wl := range Wordlists {
+		m, err := NewMnemonicWithWordlist(entropy, wl)
+		if err != nil {
+			t.Fatal(wl.Name, err)
+		}
+		if n := len(strings.Split(m, wl.Separator)); n != 12 {
+			t.Error(wl.Name, "invalid number of words", n)
+		}
+		for _, s := range []string{m, norm.NFC.String(m), norm.NFKD.String(m)} {
+			if !IsMnemonicValid(s) {
+				t.Error(wl.Name, "mnemonic should be valid", s)
+			}
+			b, err := MnemonicToEntropy(s)
+			if err != nil {
+				t.Error(wl.Name, err)
+				continue
+			}
+			if !bytes.Equal(b, entropy) {
+				t.Error(wl.Name, "entropy unmatched")
+			}
+		}
+	}
+	if _, err := DetectWordlist("abandon あいこくしん"); err != ErrUnknownWordlist {
+		t.Error("mixed wordlists should not be detected")
+	}
+}
+
+func TestJapaneseMnemonic(t *testing.T) {
+	entropy := make([]byte, 16)
+	m, err := NewMnemonicWithWordlist(entropy, Japanese)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Repeat("あいこくしん　", 11) + "あおぞら"
+	if norm.NFKD.String(m) != norm.NFKD.String(want) {
+		t.Error("invalid japanese mnemonic", m)
+	}
+	wl, err := DetectWordlist(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wl != Japanese {
+		t.Error("wordlist should be japanese", wl.Name)
+	}
+	seed := NewSeed(m, "㍍ガバヴァぱばぐゞちぢ十人十色")
+	if hex.EncodeToString(seed) != "a262d6fb6122ecf45be09c50492b31f92e9beb7d9a845987a02cefda57a15f9c467a17872029a9e92299b5cbdf306e3a0ee620245cbd508959b6cb7ca637bd55" {
+		t.Error("invalid seed", hex.EncodeToString(seed))
+	}
+}
+
+func TestPortugueseMnemonic(t *testing.T) {
+	tests := []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+	}{
+		{
+			"00000000000000000000000000000000",
+			"abacate abacate abacate abacate abacate abacate abacate abacate abacate abacate abacate abater",
+			"ab9742b024a1e8bd241b76f8b3a157e9d442da60277bc8f36b8b23afe163de79414fb49fd1a8dd26f4ea7f0dc965c760b3b80727557bdca61e1f0b0f069952f2",
+		},
+		{
+			"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			"imortal vinheta sogro xerife veleiro pomba volumoso tratador imortal vinheta sogro xingar",
+			"6c1e22f48534983f397f3ee344d384113de085bfc82f531828e7994b009a6cc86fdda9253acb5776efbc0c885acdbed8908ff59aa6aa7206484db11d13d4cf1d",
+		},
+		{
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			"zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido zumbido validade",
+			"739a6edd208d09e28fa97f8e8709aaf185e173125b1b427c04d1539173c88b78e81610a759e14f97a2038dcdc2c466a072788e3d7c88cc9bf36b96cb29510e77",
+		},
+	}
+	for _, test := range tests {
+		entropy, err := hex.DecodeString(test.entropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := NewMnemonicWithWordlist(entropy, Portuguese)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != test.mnemonic {
+			t.Error("invalid portuguese mnemonic", m)
+		}
+		wl, err := DetectWordlist(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wl != Portuguese {
+			t.Error("wordlist should be portuguese", wl.Name)
+		}
+		if seed := NewSeed(m, "TREZOR"); hex.EncodeToString(seed) != test.seed {
+			t.Error("invalid seed", hex.EncodeToString(seed))
+		}
+	}
+}
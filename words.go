@@ -28,7 +28,7 @@
 
 package address
 
-var wordlist = []string{
+var englishWords = []string{
 	"abandon",
 	"ability",
 	"able",